@@ -0,0 +1,177 @@
+package main
+
+import (
+	"context"
+	"customer-api/pkg/auth"
+	"customer-api/pkg/migrate"
+	"customer-api/pkg/repository"
+	"customer-api/pkg/requestid"
+	"customer-api/pkg/service"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const usage = `usage: customer-api [flags] [command]
+
+commands:
+  (none)              run the API server
+  migrate up          apply all pending migrations
+  migrate down [n]    revert the last n migrations (default 1)
+  migrate status      list migrations and when they were applied
+  import [-dry-run] [-format csv|ndjson] [-batch n] <file|->
+                      upsert customers by email from a CSV or NDJSON file`
+
+// importActor is the audit actor recorded for CLI imports.
+const importActor = "cli:import"
+
+// runCommand runs a subcommand given after the flags.
+func runCommand(ctx context.Context, database *gorm.DB, m *migrate.Migrator, args []string) error {
+	switch {
+	case args[0] == "migrate" && len(args) >= 2:
+		return runMigrate(ctx, m, args)
+	case args[0] == "import":
+		return runImport(ctx, database, args[1:])
+	default:
+		return fmt.Errorf("unknown command %q\n%s", args[0], usage)
+	}
+}
+
+func runMigrate(ctx context.Context, m *migrate.Migrator, args []string) error {
+	switch args[1] {
+	case "up":
+		applied, err := m.Up(ctx)
+		for _, mig := range applied {
+			fmt.Printf("applied  %04d_%s\n", mig.Version, mig.Name)
+		}
+		if err == nil && len(applied) == 0 {
+			fmt.Println("no pending migrations")
+		}
+		return err
+	case "down":
+		steps := 1
+		if len(args) > 2 {
+			n, err := strconv.Atoi(args[2])
+			if err != nil || n < 1 {
+				return fmt.Errorf("migrate down: invalid step count %q", args[2])
+			}
+			steps = n
+		}
+		reverted, err := m.Down(ctx, steps)
+		for _, mig := range reverted {
+			fmt.Printf("reverted %04d_%s\n", mig.Version, mig.Name)
+		}
+		return err
+	case "status":
+		list, err := m.Status(ctx)
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED AT")
+		for _, s := range list {
+			applied := "pending"
+			if s.AppliedAt != nil {
+				applied = s.AppliedAt.Format(time.RFC3339)
+			}
+			fmt.Fprintf(w, "%04d\t%s\t%s\n", s.Version, s.Name, applied)
+		}
+		return w.Flush()
+	default:
+		return fmt.Errorf("unknown migrate command %q\n%s", args[1], usage)
+	}
+}
+
+// runImport imports a customer file, printing the report. It fails when any
+// row failed so scripts can tell a partial import apart.
+func runImport(ctx context.Context, database *gorm.DB, args []string) error {
+	fs := flag.NewFlagSet("import", flag.ContinueOnError)
+	dryRun := fs.Bool("dry-run", false, "report changes without saving them")
+	format := fs.String("format", "", "csv or ndjson (default: from the file extension)")
+	batch := fs.Int("batch", service.DefaultImportBatchSize, "rows per transaction")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return fmt.Errorf("import: expected one file, or - for stdin\n%s", usage)
+	}
+
+	path := fs.Arg(0)
+	if *format == "" {
+		switch strings.ToLower(filepath.Ext(path)) {
+		case ".csv":
+			*format = string(service.ImportCSV)
+		case ".ndjson", ".jsonl":
+			*format = string(service.ImportNDJSON)
+		default:
+			return fmt.Errorf("import: cannot tell the format of %q; pass -format", path)
+		}
+	}
+
+	var in io.Reader = os.Stdin
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		in = f
+	}
+
+	svc := service.NewService(repository.NewRepository(database), repository.NewOutboxRepository(database),
+		repository.NewAuditRepository(database), repository.NewTransactor(database))
+
+	ctx = auth.WithPrincipal(ctx, &auth.Principal{Subject: importActor})
+	ctx = requestid.WithID(ctx, uuid.NewString())
+	report, err := svc.Import(ctx, in, service.ImportOptions{
+		Format:    service.ImportFormat(*format),
+		DryRun:    *dryRun,
+		BatchSize: *batch,
+	})
+	if report != nil {
+		printImportReport(report)
+	}
+	if err != nil {
+		return err
+	}
+	if report.Failed > 0 {
+		return errors.New("import: some rows failed")
+	}
+	return nil
+}
+
+func printImportReport(r *service.ImportReport) {
+	if r.DryRun {
+		fmt.Println("dry run: nothing was saved")
+	}
+	fmt.Printf("rows %d, created %d, updated %d, unchanged %d, failed %d\n",
+		r.Rows, r.Created, r.Updated, r.Unchanged, r.Failed)
+	if len(r.Errors) == 0 {
+		return
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "LINE\tEMAIL\tCODE\tDETAIL")
+	for _, e := range r.Errors {
+		detail := e.Detail
+		for _, field := range slices.Sorted(maps.Keys(e.Fields)) {
+			if detail != "" {
+				detail += "; "
+			}
+			detail += field + " " + e.Fields[field]
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\n", e.Line, e.Email, e.Code, detail)
+	}
+	w.Flush()
+}
@@ -0,0 +1,18 @@
+package main
+
+import "customer-api/pkg/ratelimit"
+
+// routeLimits override the default per-client rate limit for routes that
+// are expensive or sensitive.
+var routeLimits = map[string]ratelimit.Limit{
+	// loads every customer's feedback and looks up each product
+	"GET /customers": {Rate: 2, Burst: 5},
+	"POST /publish":  {Rate: 1, Burst: 5},
+	// each call reads a whole table
+	"GET /exports/customers":    {Rate: 0.1, Burst: 2},
+	"GET /exports/feedbacks":    {Rate: 0.1, Burst: 2},
+	"GET /exports/interactions": {Rate: 0.1, Burst: 2},
+	// each call may upsert thousands of rows
+	"POST /customers/import": {Rate: 0.2, Burst: 2},
+	"POST /api-keys":         {Rate: 0.1, Burst: 3},
+}
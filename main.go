@@ -1,80 +1,308 @@
 package main
 
 import (
+	"context"
+	"customer-api/pkg/auth"
+	"customer-api/pkg/config"
 	"customer-api/pkg/db"
 	"customer-api/pkg/handler"
+	"customer-api/pkg/health"
+	"customer-api/pkg/logging"
 	"customer-api/pkg/messaging"
-	"customer-api/pkg/model"
+	"customer-api/pkg/metrics"
+	"customer-api/pkg/migrate"
+	"customer-api/pkg/ratelimit"
 	"customer-api/pkg/repository"
+	"customer-api/pkg/requestid"
 	"customer-api/pkg/service"
-	"log"
+	"customer-api/pkg/tracing"
+	"fmt"
+	"log/slog"
+	"net/http"
 	"os"
+	"os/signal"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
-	"github.com/joho/godotenv"
 )
 
 func main() {
-	err := godotenv.Load()
+	cfg, args, err := config.Load(os.Args[1:])
 	if err != nil {
-		log.Fatal("Error loading .env file")
+		fatal("load config", err)
 	}
 
-	r := gin.Default()
+	logger := logging.New(os.Stdout, cfg.Log.Format, cfg.Log.Level)
+	slog.SetDefault(logger)
 
-	database := db.NewPostgresDB()
+	r := gin.New()
+	// without trusted proxies a client's IP is the connection's peer, so
+	// X-Forwarded-For cannot be forged to dodge per-IP limits
+	if err := r.SetTrustedProxies(cfg.HTTP.TrustedProxies); err != nil {
+		fatal("set trusted proxies", err)
+	}
+
+	database, err := db.NewPostgresDB(cfg.DB)
+	if err != nil {
+		fatal("connect database", err)
+	}
+
+	sqlDB, err := database.DB()
+	if err != nil {
+		fatal("connect database", err)
+	}
+	migrator, err := migrate.New(sqlDB)
+	if err != nil {
+		fatal("load migrations", err)
+	}
 
-	// Auto migrate
-	if err := database.AutoMigrate(&model.Customer{},
-		&model.Product{},
-		&model.Feedback{},
-		&model.Interaction{},
-	); err != nil {
-		log.Fatalf("Migrate failed: %v", err)
+	if len(args) > 0 {
+		if err := runCommand(context.Background(), database, migrator, args); err != nil {
+			fatal(args[0], err)
+		}
+		return
 	}
 
+	if cfg.DB.MigrateOnStart {
+		applied, err := migrator.Up(context.Background())
+		if err != nil {
+			fatal("migrate", err)
+		}
+		for _, m := range applied {
+			logger.Info("applied migration", "version", m.Version, "name", m.Name)
+		}
+	}
+
+	shutdownTracing, err := tracing.Setup(context.Background(), cfg.Tracing)
+	if err != nil {
+		fatal("set up tracing", err)
+	}
+
+	if err := database.Use(metrics.GormPlugin{}); err != nil {
+		fatal("register gorm metrics", err)
+	}
+	if err := database.Use(tracing.GormPlugin{}); err != nil {
+		fatal("register gorm tracing", err)
+	}
+	if err := metrics.RegisterDBStats(sqlDB); err != nil {
+		fatal("register db stats", err)
+	}
+
+	eventPublisher := messaging.NewKafkaPublisher(cfg.Kafka.Brokers)
+
+	checker := health.NewChecker(cfg.HTTP.HealthTimeout)
+	checker.Add("postgres", sqlDB.PingContext)
+	checker.Add("kafka", eventPublisher.Ping)
+	checker.Add("migrations", migrator.Current)
+
 	// inject dependencies
+	transactor := repository.NewTransactor(database)
+	outboxRepository := repository.NewOutboxRepository(database)
+	auditRepository := repository.NewAuditRepository(database)
 	cusRepo := repository.NewRepository(database)
-	cusService := service.NewService(cusRepo)
+	cusService := service.NewService(cusRepo, outboxRepository, auditRepository, transactor)
 	productRepository := repository.NewProductRepository(database)
+	productService := service.NewProductService(productRepository, auditRepository, transactor)
 	cusHandler := handler.NewCustomerHandler(cusService, productRepository)
-	feedbackHandler := handler.NewFeedbackHandler(database)
+	productHandler := handler.NewProductHandler(productService)
+	interactionRepository := repository.NewInteractionRepository(database)
+	interactionService := service.NewInteractionService(interactionRepository, cusRepo, auditRepository, transactor)
+	interactionHandler := handler.NewInteractionHandler(interactionService)
+	feedbackRepository := repository.NewFeedbackRepository(database)
+	feedbackService := service.NewFeedbackService(feedbackRepository, cusRepo, productRepository, outboxRepository, auditRepository, transactor)
+	feedbackHandler := handler.NewFeedbackHandler(feedbackService)
+	apiKeyService := service.NewAPIKeyService(repository.NewAPIKeyRepository(database), auditRepository, transactor)
+	apiKeyHandler := handler.NewAPIKeyHandler(apiKeyService)
+	auditHandler := handler.NewAuditHandler(service.NewAuditService(auditRepository))
+	exportHandler := handler.NewExportHandler(cusService, feedbackService, interactionService, cfg.HTTP.ExportTimeout)
+	privacyHandler := handler.NewPrivacyHandler(service.NewPrivacyService(repository.NewPrivacyRepository(database), outboxRepository, auditRepository, transactor))
 
 	// Middleware
-	r.Use(gin.Logger(), gin.Recovery())
+	r.Use(tracing.Middleware(), requestid.Middleware(), logging.Middleware(logger), logging.Recovery(), metrics.Middleware(), handler.Errors())
 	r.Use(cors.New(cors.Config{
-		AllowOrigins:  []string{"*"},
-		AllowHeaders:  []string{"Origin", "Content-Type", "Authorization"},
+		AllowOrigins:  cfg.CORS.AllowOrigins,
+		AllowHeaders:  []string{"Origin", "Content-Type", "Authorization", requestid.Header},
 		AllowMethods:  []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		ExposeHeaders: []string{"Content-Length"},
+		ExposeHeaders: []string{"Content-Length", requestid.Header},
 	}))
 
-	customer := r.Group("customers")
-	customer.GET("", cusHandler.Get)
-	customer.POST("", cusHandler.CreateCustomer)
-	customer.DELETE("/:id", cusHandler.DeleteByID)
-	customer.PUT("/:id", cusHandler.UpdateByID)
-	customer.GET("/:id", cusHandler.GetByID)
+	// routes registered before the auth middleware are public
+	apiDoc := apiDocument()
+	registerPublicRoutes(r, checker, apiDoc, cfg.Features.Metrics)
+	publicRoutes := r.Routes()
 
-	feedbackGroup := r.Group("/feedbacks")
-	{
-		feedbackGroup.POST("", feedbackHandler.CreateFeedback)
-		feedbackGroup.GET("", feedbackHandler.ListFeedbacks)
-		feedbackGroup.GET("/:id", feedbackHandler.GetFeedback)
-		feedbackGroup.PUT("/:id", feedbackHandler.UpdateFeedback)
-		feedbackGroup.DELETE("/:id", feedbackHandler.DeleteFeedback)
+	if cfg.Auth.Enabled {
+		verifier, err := auth.NewVerifier(cfg.Auth)
+		if err != nil {
+			fatal("load auth keys", err)
+		}
+		if cfg.RateLimit.Enabled {
+			r.Use(ratelimit.IPMiddleware(ratelimit.NewMemoryStore(), ratelimit.Limit{
+				Rate:  cfg.RateLimit.IPRPS,
+				Burst: cfg.RateLimit.IPBurst,
+			}))
+		}
+		r.Use(auth.Middleware(verifier, apiKeyService), auth.Authorize(routePolicy))
+	}
+	if cfg.RateLimit.Enabled {
+		r.Use(ratelimit.Middleware(ratelimit.NewMemoryStore(), ratelimit.Limit{
+			Rate:  cfg.RateLimit.RPS,
+			Burst: cfg.RateLimit.Burst,
+		}, routeLimits))
+		if cfg.RateLimit.Quota > 0 {
+			r.Use(ratelimit.QuotaMiddleware(ratelimit.NewMemoryStore(), ratelimit.Quota{
+				Requests: cfg.RateLimit.Quota,
+				Window:   cfg.RateLimit.QuotaWindow,
+			}))
+		}
 	}
 
-	kafkaHandler := handler.NewKafkaHandler([]string{"kafka:9092"}, "my-topic")
-	defer kafkaHandler.Close()
-	r.POST("/publish", kafkaHandler.Publish)
+	r.NoRoute(handler.NotFound)
+
+	// closed last on shutdown, after everything using them has stopped
+	closers := []namedCloser{{"kafka publisher", eventPublisher.Close}}
+	var kafkaHandler *handler.KafkaHandler
+	if cfg.Features.PublishEndpoint {
+		kafkaHandler = handler.NewKafkaHandler(cfg.Kafka.Brokers, cfg.Kafka.PublishTopic)
+		closers = append(closers, namedCloser{"kafka publish endpoint writer", kafkaHandler.Close})
+	}
+	closers = append(closers, namedCloser{"database", sqlDB.Close})
 
-	go messaging.NewSub()
+	registerRoutes(r, handlers{
+		customers:    cusHandler,
+		products:     productHandler,
+		interactions: interactionHandler,
+		feedbacks:    feedbackHandler,
+		apiKeys:      apiKeyHandler,
+		audit:        auditHandler,
+		exports:      exportHandler,
+		privacy:      privacyHandler,
+		kafka:        kafkaHandler,
+	})
 
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "8080"
+	// background workers stop when workers is cancelled
+	workers, stopWorkers := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+
+	if cfg.Features.Consumer {
+		consumer := messaging.NewConsumer(messaging.ConsumerConfig{
+			Brokers:         cfg.Kafka.Brokers,
+			GroupID:         cfg.Kafka.ConsumerGroup,
+			DeadLetterTopic: cfg.Kafka.DeadLetterTopic,
+			MaxRetries:      cfg.Kafka.MaxRetries,
+		})
+		for _, topic := range cfg.Kafka.ConsumerTopics {
+			consumer.Handle(topic, messaging.LogHandler)
+		}
+		checker.Add("consumer", consumer.Running)
+		consumerCtx := logging.WithLogger(workers, logger.With("component", "consumer"))
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := consumer.Run(consumerCtx); err != nil {
+				logger.Error("consumer stopped", "error", err)
+			}
+		}()
+	}
+	if cfg.Features.OutboxRelay {
+		relayCtx := logging.WithLogger(workers, logger.With("component", "outbox-relay"))
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			relay := messaging.NewOutboxRelay(outboxRepository, eventPublisher)
+			relay.MaxAttempts = cfg.Kafka.OutboxMaxAttempts
+			relay.Run(relayCtx)
+		}()
+	}
+
+	if undocumented, stale := apiDoc.Diff(r.Routes(), undocumentedRoutes...); len(undocumented)+len(stale) > 0 {
+		// main_test.go keeps the table in step; a drift here only leaves
+		// the published document incomplete
+		logger.Warn("openapi document out of date", "undocumented", undocumented, "stale", stale)
+	}
+	if cfg.Auth.Enabled {
+		if missing := routePolicy.Missing(r.Routes(), publicRoutes); len(missing) > 0 {
+			fatal("check access policy", fmt.Errorf("routes without access policy: %v", missing))
+		}
+	}
+
+	srv := &http.Server{
+		Addr:         ":" + strconv.Itoa(cfg.HTTP.Port),
+		Handler:      r,
+		ReadTimeout:  cfg.HTTP.ReadTimeout,
+		WriteTimeout: cfg.HTTP.WriteTimeout,
+		IdleTimeout:  cfg.HTTP.IdleTimeout,
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	serveErr := make(chan error, 1)
+	go func() {
+		logger.Info("listening", "addr", srv.Addr)
+		serveErr <- srv.ListenAndServe()
+	}()
+	select {
+	case err := <-serveErr:
+		fatal("serve", err)
+	case <-ctx.Done():
+	}
+	// a second signal kills the process right away
+	stop()
+
+	logger.Info("shutting down", "delay", cfg.Shutdown.Delay.String(), "timeout", cfg.Shutdown.Timeout.String())
+	deadline, cancel := context.WithTimeout(context.Background(), cfg.Shutdown.Timeout)
+	defer cancel()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+
+		checker.Shutdown()
+		select {
+		case <-time.After(cfg.Shutdown.Delay):
+		case <-deadline.Done():
+		}
+
+		// stop accepting connections and wait for in-flight requests
+		if err := srv.Shutdown(deadline); err != nil {
+			logger.Error("drain http requests", "error", err)
+		}
+
+		// the consumer commits the message in hand, the relay finishes
+		// its batch
+		stopWorkers()
+		wg.Wait()
+
+		if err := shutdownTracing(deadline); err != nil {
+			logger.Error("flush traces", "error", err)
+		}
+		for _, c := range closers {
+			if err := c.close(); err != nil {
+				logger.Error("close "+c.name, "error", err)
+			}
+		}
+	}()
+
+	select {
+	case <-done:
+		logger.Info("stopped")
+	case <-deadline.Done():
+		fatal("shutdown", fmt.Errorf("not finished within %s", cfg.Shutdown.Timeout))
 	}
-	r.Run(":" + port)
+}
+
+type namedCloser struct {
+	name  string
+	close func() error
+}
+
+// fatal logs err and exits. Deferred calls do not run.
+func fatal(msg string, err error) {
+	slog.Error(msg, "error", err)
+	os.Exit(1)
 }
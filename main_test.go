@@ -0,0 +1,40 @@
+package main
+
+import (
+	"customer-api/pkg/handler"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TestRouteTables builds the router the way main does, with every feature
+// on, and checks that routeDocs, routePolicy and routeLimits match it.
+func TestRouteTables(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	apiDoc := apiDocument()
+	registerPublicRoutes(r, nil, apiDoc, true)
+	publicRoutes := r.Routes()
+	registerRoutes(r, handlers{kafka: new(handler.KafkaHandler)})
+
+	undocumented, stale := apiDoc.Diff(r.Routes(), undocumentedRoutes...)
+	if len(undocumented) > 0 {
+		t.Errorf("routes missing from routeDocs: %v", undocumented)
+	}
+	if len(stale) > 0 {
+		t.Errorf("documented routes not registered: %v", stale)
+	}
+	if missing := routePolicy.Missing(r.Routes(), publicRoutes); len(missing) > 0 {
+		t.Errorf("routes without access policy: %v", missing)
+	}
+
+	registered := make(map[string]bool)
+	for _, route := range r.Routes() {
+		registered[route.Method+" "+route.Path] = true
+	}
+	for key := range routeLimits {
+		if !registered[key] {
+			t.Errorf("routeLimits has unregistered route %q", key)
+		}
+	}
+}
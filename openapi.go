@@ -0,0 +1,166 @@
+package main
+
+import (
+	"customer-api/pkg/export"
+	"customer-api/pkg/handler"
+	"customer-api/pkg/health"
+	"customer-api/pkg/model"
+	"customer-api/pkg/openapi"
+	"customer-api/pkg/pagination"
+	"customer-api/pkg/service"
+	"net/http"
+	"slices"
+)
+
+// undocumentedRoutes serve the documentation itself.
+var undocumentedRoutes = []string{"GET /docs/*filepath"}
+
+// channelParam filters interactions by contact channel.
+var channelParam = func() openapi.Parameter {
+	p := openapi.Query("channel", "Contact channel")
+	p.Schema.Enum = service.Channels
+	return p
+}()
+
+var interactionQuery = []openapi.Parameter{
+	channelParam,
+	openapi.Query("from", "Logged at or after, RFC3339 or YYYY-MM-DD"),
+	openapi.Query("to", "Logged before, RFC3339 or YYYY-MM-DD (a bare date includes the whole day)"),
+}
+
+// exportFormat selects the file format of an export.
+var exportFormat = func() openapi.Parameter {
+	p := openapi.Query("format", "File format (default csv)")
+	p.Schema.Enum = export.Formats
+	return p
+}()
+
+var exportTypes = []string{export.CSV.ContentType(), export.NDJSON.ContentType(), export.XLSX.ContentType()}
+
+type messageResponse struct {
+	Message string `json:"message"`
+}
+
+type statusResponse struct {
+	Status string `json:"status"`
+}
+
+// routeDocs documents every route for GET /openapi.json. TestRouteTables
+// fails if a registered route is missing from this table.
+var routeDocs = []openapi.Route{
+	{Method: "GET", Path: "/customers", Tag: "Customers", Summary: "List customers with their feedback grouped by product",
+		Query: []openapi.Parameter{openapi.Query("keyword", "Search name, email or phone")}, Paged: true,
+		Response: pagination.Page[service.CustomerResponse]{}},
+	{Method: "POST", Path: "/customers", Tag: "Customers", Summary: "Create a customer",
+		Body: service.CreateCustomerRequest{}, Response: model.Customer{}, Status: http.StatusCreated,
+		Errors: []int{http.StatusConflict}},
+	{Method: "POST", Path: "/customers/import", Tag: "Customers", Summary: "Upsert customers by email from a CSV or NDJSON file",
+		Query: []openapi.Parameter{
+			openapi.Query("format", "csv or ndjson; defaults to the Content-Type"),
+			openapi.Query("dry_run", "true to report changes without saving them"),
+		},
+		Uploads: []string{"text/csv", "application/x-ndjson"}, Response: service.ImportReport{},
+		Errors: []int{http.StatusRequestEntityTooLarge}},
+	{Method: "GET", Path: "/customers/:id", Tag: "Customers", Summary: "Get a customer",
+		Response: model.Customer{}},
+	{Method: "PUT", Path: "/customers/:id", Tag: "Customers", Summary: "Update a customer",
+		Body: service.UpdateCustomerRequest{}, Response: model.Customer{}, Errors: []int{http.StatusConflict}},
+	{Method: "DELETE", Path: "/customers/:id", Tag: "Customers", Summary: "Delete a customer",
+		Response: messageResponse{}},
+	{Method: "POST", Path: "/customers/:id/interactions", Tag: "Interactions", Summary: "Log an interaction with a customer",
+		Body: service.CreateInteractionRequest{}, Response: model.Interaction{}, Status: http.StatusCreated},
+	{Method: "GET", Path: "/customers/:id/interactions", Tag: "Interactions", Summary: "List a customer's interactions",
+		Query: interactionQuery, Paged: true, Response: pagination.Page[model.Interaction]{}},
+	{Method: "GET", Path: "/customers/:id/personal-data", Tag: "Privacy", Summary: "Download everything stored about a customer (PDPA subject access)",
+		Response: service.PersonalData{}},
+	{Method: "POST", Path: "/customers/:id/erase", Tag: "Privacy", Summary: "Irreversibly anonymise a customer's personal data (PDPA erasure)",
+		Response: service.ErasureResult{}},
+
+	{Method: "GET", Path: "/products", Tag: "Products", Summary: "List products",
+		Query: []openapi.Parameter{openapi.Query("keyword", "Search name"), openapi.Query("category", "Exact category")},
+		Paged: true, Response: pagination.Page[model.Product]{}},
+	{Method: "POST", Path: "/products", Tag: "Products", Summary: "Create a product",
+		Body: service.CreateProductRequest{}, Response: model.Product{}, Status: http.StatusCreated},
+	{Method: "GET", Path: "/products/:id", Tag: "Products", Summary: "Get a product",
+		Response: model.Product{}},
+	{Method: "PUT", Path: "/products/:id", Tag: "Products", Summary: "Update a product",
+		Body: service.UpdateProductRequest{}, Response: model.Product{}},
+	{Method: "DELETE", Path: "/products/:id", Tag: "Products", Summary: "Delete a product",
+		Status: http.StatusNoContent},
+
+	{Method: "GET", Path: "/feedbacks", Tag: "Feedback", Summary: "List feedback",
+		Query: []openapi.Parameter{openapi.QueryUUID("customer_id", "Only this customer's"), openapi.QueryUUID("product_id", "Only for this product")},
+		Paged: true, Response: pagination.Page[model.Feedback]{}},
+	{Method: "POST", Path: "/feedbacks", Tag: "Feedback", Summary: "Submit feedback",
+		Body: handler.FeedbackRequest{}, Response: handler.FeedbackResponse{}, Status: http.StatusCreated,
+		Errors: []int{http.StatusNotFound}},
+	{Method: "GET", Path: "/feedbacks/:id", Tag: "Feedback", Summary: "Get feedback",
+		Response: model.Feedback{}},
+	{Method: "PUT", Path: "/feedbacks/:id", Tag: "Feedback", Summary: "Update feedback",
+		Body: handler.FeedbackRequest{}, Response: model.Feedback{}},
+	{Method: "DELETE", Path: "/feedbacks/:id", Tag: "Feedback", Summary: "Delete feedback",
+		Status: http.StatusNoContent},
+
+	{Method: "GET", Path: "/interactions", Tag: "Interactions", Summary: "List interactions",
+		Query: append([]openapi.Parameter{openapi.QueryUUID("customer_id", "Only this customer's")}, interactionQuery...),
+		Paged: true, Response: pagination.Page[model.Interaction]{}},
+	{Method: "GET", Path: "/interactions/:id", Tag: "Interactions", Summary: "Get an interaction",
+		Response: model.Interaction{}},
+	{Method: "PUT", Path: "/interactions/:id", Tag: "Interactions", Summary: "Update an interaction",
+		Body: service.UpdateInteractionRequest{}, Response: model.Interaction{}},
+	{Method: "DELETE", Path: "/interactions/:id", Tag: "Interactions", Summary: "Delete an interaction",
+		Status: http.StatusNoContent},
+
+	{Method: "POST", Path: "/api-keys", Tag: "API keys", Summary: "Create an API key; the key is only shown in this response",
+		Body: service.CreateAPIKeyRequest{}, Response: service.CreatedAPIKey{}, Status: http.StatusCreated},
+	{Method: "GET", Path: "/api-keys", Tag: "API keys", Summary: "List API keys",
+		Response: []model.APIKey{}},
+	{Method: "GET", Path: "/api-keys/:id", Tag: "API keys", Summary: "Get an API key",
+		Response: model.APIKey{}},
+	{Method: "DELETE", Path: "/api-keys/:id", Tag: "API keys", Summary: "Revoke an API key",
+		Status: http.StatusNoContent},
+
+	{Method: "GET", Path: "/audit-logs", Tag: "Audit", Summary: "List audit log entries, newest first",
+		Query: []openapi.Parameter{
+			openapi.Query("entity_type", "customer, product, feedback, interaction or api_key"),
+			openapi.QueryUUID("entity_id", "Only this entity's history"),
+			openapi.Query("actor", "Token subject or API key that made the change"),
+		},
+		Paged: true, Response: pagination.Page[model.AuditLog]{}},
+
+	{Method: "GET", Path: "/exports/customers", Tag: "Exports", Summary: "Download customers, filtered as in GET /customers",
+		Query:     []openapi.Parameter{exportFormat, openapi.Query("keyword", "Search name, email or phone")},
+		Downloads: exportTypes},
+	{Method: "GET", Path: "/exports/feedbacks", Tag: "Exports", Summary: "Download feedback, filtered as in GET /feedbacks",
+		Query:     []openapi.Parameter{exportFormat, openapi.QueryUUID("customer_id", "Only this customer's"), openapi.QueryUUID("product_id", "Only for this product")},
+		Downloads: exportTypes},
+	{Method: "GET", Path: "/exports/interactions", Tag: "Exports", Summary: "Download interactions, filtered as in GET /interactions",
+		Query:     append([]openapi.Parameter{exportFormat, openapi.QueryUUID("customer_id", "Only this customer's")}, interactionQuery...),
+		Downloads: exportTypes},
+
+	{Method: "POST", Path: "/publish", Tag: "Messaging", Summary: "Publish a raw message to the publish topic",
+		Body: handler.PublishRequest{}, Response: statusResponse{}, Optional: true},
+
+	{Method: "GET", Path: "/livez", Tag: "Operations", Summary: "Liveness probe",
+		Response: health.Report{}},
+	{Method: "GET", Path: "/readyz", Tag: "Operations", Summary: "Readiness probe; 503 with the same body when a check fails",
+		Response: health.Report{}},
+	{Method: "GET", Path: "/metrics", Tag: "Operations", Summary: "Prometheus metrics",
+		Response: "", ContentType: "text/plain", Optional: true},
+	{Method: "GET", Path: "/openapi.json", Tag: "Operations", Summary: "This document",
+		Response: map[string]any{}},
+}
+
+// apiDocument builds the OpenAPI document, taking each route's permission
+// from routePolicy.
+func apiDocument() *openapi.Document {
+	routes := slices.Clone(routeDocs)
+	for i, r := range routes {
+		routes[i].Permission = string(routePolicy[r.Method+" "+r.Path])
+	}
+	return openapi.Build(openapi.Info{
+		Title:       "Customer API",
+		Version:     "1.0.0",
+		Description: "Customers, products, feedback and interactions. Errors are RFC 7807 problem details.",
+	}, routes)
+}
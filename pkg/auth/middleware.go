@@ -0,0 +1,74 @@
+package auth
+
+import (
+	"context"
+	"customer-api/pkg/problem"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ContextKey is the gin context key holding the *Principal.
+const ContextKey = "principal"
+
+// APIKeyPrefix starts every API key, which tells keys and JWTs apart.
+const APIKeyPrefix = "cak_"
+
+// ErrInvalidCredential is returned, possibly wrapped, by a KeyAuthenticator
+// for a key that is unknown, wrong, revoked or expired. Any other error
+// means the key could not be checked.
+var ErrInvalidCredential = errors.New("invalid credential")
+
+// KeyAuthenticator resolves an API key to the principal it stands for.
+type KeyAuthenticator interface {
+	Authenticate(ctx context.Context, key string) (*Principal, error)
+}
+
+// Middleware rejects requests without a valid credential and stores the
+// principal in both the gin context and the request context. A credential
+// is a JWT or API key sent as a bearer token, or an API key in X-API-Key.
+func Middleware(v *Verifier, keys KeyAuthenticator) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token := c.GetHeader("X-API-Key")
+		if token == "" {
+			scheme, bearer, ok := strings.Cut(c.GetHeader("Authorization"), " ")
+			if ok && strings.EqualFold(scheme, "Bearer") {
+				token = strings.TrimSpace(bearer)
+			}
+		}
+		if token == "" {
+			unauthorized(c, "missing bearer token")
+			return
+		}
+
+		var p *Principal
+		var err error
+		if strings.HasPrefix(token, APIKeyPrefix) {
+			p, err = keys.Authenticate(c.Request.Context(), token)
+			if errors.Is(err, ErrInvalidCredential) {
+				unauthorized(c, "invalid, revoked or expired api key")
+				return
+			}
+			if err != nil {
+				// an outage is the server's fault, not a bad credential
+				c.Error(err)
+				c.Abort()
+				return
+			}
+		} else if p, err = v.Verify(token); err != nil {
+			unauthorized(c, err.Error())
+			return
+		}
+
+		c.Set(ContextKey, p)
+		c.Request = c.Request.WithContext(WithPrincipal(c.Request.Context(), p))
+		c.Next()
+	}
+}
+
+func unauthorized(c *gin.Context, msg string) {
+	c.Header("WWW-Authenticate", `Bearer error="invalid_token"`)
+	problem.Abort(c, problem.New(http.StatusUnauthorized, "unauthorized", msg))
+}
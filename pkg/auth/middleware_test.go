@@ -0,0 +1,91 @@
+package auth
+
+import (
+	"context"
+	"customer-api/pkg/config"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+type fakeKeys struct {
+	principal *Principal
+	err       error
+}
+
+func (f fakeKeys) Authenticate(context.Context, string) (*Principal, error) {
+	return f.principal, f.err
+}
+
+func TestMiddlewareAPIKeys(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	v, err := NewVerifier(config.AuthConfig{HS256Secret: strings.Repeat("s", 32)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		keys       fakeKeys
+		header     string
+		want       int
+		wantDetail string
+	}{
+		{"valid key", fakeKeys{principal: &Principal{Subject: "apikey:1"}}, "X-API-Key", http.StatusOK, ""},
+		{"valid bearer key", fakeKeys{principal: &Principal{Subject: "apikey:1"}}, "Authorization", http.StatusOK, ""},
+		{"revoked key", fakeKeys{err: fmt.Errorf("%w: revoked", ErrInvalidCredential)}, "X-API-Key", http.StatusUnauthorized, "invalid, revoked or expired api key"},
+		{"database down", fakeKeys{err: errors.New("dial tcp 10.0.0.5:5432: connection refused")}, "X-API-Key", http.StatusInternalServerError, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := gin.New()
+			// stands in for handler.Errors
+			r.Use(func(c *gin.Context) {
+				c.Next()
+				if len(c.Errors) > 0 && !c.Writer.Written() {
+					c.String(http.StatusInternalServerError, "internal error")
+				}
+			}, Middleware(v, tt.keys))
+			r.GET("/", func(c *gin.Context) {
+				c.String(http.StatusOK, Subject(c.Request.Context()))
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.header == "Authorization" {
+				req.Header.Set("Authorization", "Bearer "+APIKeyPrefix+"x")
+			} else {
+				req.Header.Set("X-API-Key", APIKeyPrefix+"x")
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if w.Code != tt.want {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.want, w.Body)
+			}
+			if tt.wantDetail != "" && !strings.Contains(w.Body.String(), tt.wantDetail) {
+				t.Errorf("body = %s, want detail %q", w.Body, tt.wantDetail)
+			}
+			if strings.Contains(w.Body.String(), "10.0.0.5") {
+				t.Errorf("body leaks the database error: %s", w.Body)
+			}
+		})
+	}
+}
+
+func TestMiddlewareMissingCredential(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(Middleware(&Verifier{}, fakeKeys{}))
+	r.GET("/", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	if w.Code != http.StatusUnauthorized || w.Header().Get("WWW-Authenticate") == "" {
+		t.Errorf("status = %d, WWW-Authenticate = %q", w.Code, w.Header().Get("WWW-Authenticate"))
+	}
+}
@@ -0,0 +1,33 @@
+package auth
+
+import "context"
+
+// Principal is the authenticated caller of a request: a user with roles
+// from a token, or a service client with the scopes of its API key.
+type Principal struct {
+	Subject string
+	Roles   []string
+	Scopes  []Permission
+}
+
+type principalKey struct{}
+
+// WithPrincipal returns a copy of ctx carrying p.
+func WithPrincipal(ctx context.Context, p *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+// FromContext returns the principal of the request, or nil when the
+// request is not authenticated.
+func FromContext(ctx context.Context) *Principal {
+	p, _ := ctx.Value(principalKey{}).(*Principal)
+	return p
+}
+
+// Subject returns the subject of the principal in ctx, or "anonymous".
+func Subject(ctx context.Context) string {
+	if p := FromContext(ctx); p != nil {
+		return p.Subject
+	}
+	return "anonymous"
+}
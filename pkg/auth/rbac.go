@@ -0,0 +1,140 @@
+package auth
+
+import (
+	"customer-api/pkg/problem"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Permission is a single action on a resource, e.g. customers:read.
+type Permission string
+
+const (
+	CustomersRead      Permission = "customers:read"
+	CustomersWrite     Permission = "customers:write"
+	CustomersDelete    Permission = "customers:delete"
+	ProductsRead       Permission = "products:read"
+	ProductsWrite      Permission = "products:write"
+	ProductsDelete     Permission = "products:delete"
+	FeedbackRead       Permission = "feedback:read"
+	FeedbackWrite      Permission = "feedback:write"
+	FeedbackDelete     Permission = "feedback:delete"
+	InteractionsRead   Permission = "interactions:read"
+	InteractionsWrite  Permission = "interactions:write"
+	InteractionsDelete Permission = "interactions:delete"
+	Publish            Permission = "publish"
+	ManageAPIKeys      Permission = "apikeys:manage"
+	AuditRead          Permission = "audit:read"
+	ExportsRead        Permission = "exports:read"
+	PersonalDataExport Permission = "personal-data:export"
+	PersonalDataErase  Permission = "personal-data:erase"
+)
+
+// AllPermissions lists every permission known to the service.
+var AllPermissions = []Permission{
+	CustomersRead, CustomersWrite, CustomersDelete,
+	ProductsRead, ProductsWrite, ProductsDelete,
+	FeedbackRead, FeedbackWrite, FeedbackDelete,
+	InteractionsRead, InteractionsWrite, InteractionsDelete,
+	Publish, ManageAPIKeys, AuditRead, ExportsRead,
+	PersonalDataExport, PersonalDataErase,
+}
+
+// IsPermission reports whether s names a known permission.
+func IsPermission(s string) bool {
+	for _, p := range AllPermissions {
+		if string(p) == s {
+			return true
+		}
+	}
+	return false
+}
+
+const (
+	RoleAdmin   = "admin"
+	RoleAgent   = "agent"
+	RoleAnalyst = "analyst"
+)
+
+var readOnly = []Permission{CustomersRead, ProductsRead, FeedbackRead, InteractionsRead}
+
+// RolePermissions grants permissions to the roles found in the token.
+var RolePermissions = map[string][]Permission{
+	RoleAnalyst: append(append([]Permission{}, readOnly...), ExportsRead),
+	RoleAgent:   append(append([]Permission{}, readOnly...), InteractionsWrite),
+	RoleAdmin:   AllPermissions,
+}
+
+// Can reports whether a scope or any role of p grants perm.
+func (p *Principal) Can(perm Permission) bool {
+	for _, scope := range p.Scopes {
+		if scope == perm {
+			return true
+		}
+	}
+	for _, role := range p.Roles {
+		for _, granted := range RolePermissions[role] {
+			if granted == perm {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Policy maps "METHOD /route/:template" to the permission it requires.
+type Policy map[string]Permission
+
+// Missing returns the routes that have no entry in the policy, ignoring
+// the public routes, which are served without credentials.
+func (p Policy) Missing(routes, public gin.RoutesInfo) []string {
+	open := make(map[string]bool, len(public))
+	for _, r := range public {
+		open[r.Method+" "+r.Path] = true
+	}
+
+	var missing []string
+	for _, r := range routes {
+		if open[r.Method+" "+r.Path] {
+			continue
+		}
+		if _, ok := p[r.Method+" "+r.Path]; !ok {
+			missing = append(missing, r.Method+" "+r.Path)
+		}
+	}
+	return missing
+}
+
+// Authorize enforces policy on the matched route. A route without a policy
+// entry is denied so new routes are closed until someone grants access.
+func Authorize(policy Policy) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		route := c.FullPath()
+		if route == "" {
+			// no route matched, let gin answer 404
+			c.Next()
+			return
+		}
+
+		perm, ok := policy[c.Request.Method+" "+route]
+		if !ok {
+			problem.Abort(c, problem.New(http.StatusForbidden, "no_policy",
+				fmt.Sprintf("no access policy for %s %s", c.Request.Method, route)))
+			return
+		}
+
+		p := FromContext(c.Request.Context())
+		if p == nil {
+			unauthorized(c, "missing bearer token")
+			return
+		}
+		if !p.Can(perm) {
+			problem.Abort(c, problem.New(http.StatusForbidden, "forbidden", "missing permission "+string(perm)).
+				With("missingPermission", perm))
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,111 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestPrincipalCan(t *testing.T) {
+	tests := []struct {
+		name string
+		p    Principal
+		perm Permission
+		want bool
+	}{
+		{"admin has everything", Principal{Roles: []string{RoleAdmin}}, PersonalDataErase, true},
+		{"analyst reads", Principal{Roles: []string{RoleAnalyst}}, CustomersRead, true},
+		{"analyst exports", Principal{Roles: []string{RoleAnalyst}}, ExportsRead, true},
+		{"analyst cannot write", Principal{Roles: []string{RoleAnalyst}}, CustomersWrite, false},
+		{"agent logs interactions", Principal{Roles: []string{RoleAgent}}, InteractionsWrite, true},
+		{"agent cannot export", Principal{Roles: []string{RoleAgent}}, ExportsRead, false},
+		{"roles add up", Principal{Roles: []string{RoleAgent, RoleAnalyst}}, ExportsRead, true},
+		{"unknown role grants nothing", Principal{Roles: []string{"root"}}, CustomersRead, false},
+		{"scope grants", Principal{Scopes: []Permission{FeedbackWrite}}, FeedbackWrite, true},
+		{"scope grants only itself", Principal{Scopes: []Permission{FeedbackWrite}}, FeedbackRead, false},
+		{"nobody", Principal{}, CustomersRead, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.p.Can(tt.perm); got != tt.want {
+				t.Errorf("Can(%s) = %v, want %v", tt.perm, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRolePermissionsAreKnown(t *testing.T) {
+	for role, perms := range RolePermissions {
+		for _, p := range perms {
+			if !IsPermission(string(p)) {
+				t.Errorf("role %s grants unknown permission %q", role, p)
+			}
+		}
+	}
+}
+
+func TestPolicyMissing(t *testing.T) {
+	route := func(method, path string) gin.RouteInfo { return gin.RouteInfo{Method: method, Path: path} }
+	policy := Policy{
+		"GET /customers":     CustomersRead,
+		"GET /customers/:id": CustomersRead,
+	}
+	routes := gin.RoutesInfo{
+		route("GET", "/livez"),
+		route("GET", "/customers"),
+		route("GET", "/customers/:id"),
+		route("POST", "/customers"),
+		route("DELETE", "/customers/:id"),
+	}
+	public := gin.RoutesInfo{route("GET", "/livez")}
+
+	got := policy.Missing(routes, public)
+	want := []string{"POST /customers", "DELETE /customers/:id"}
+	if !slices.Equal(got, want) {
+		t.Errorf("Missing() = %v, want %v", got, want)
+	}
+	if got := policy.Missing(routes[:3], public); len(got) != 0 {
+		t.Errorf("Missing() = %v, want none", got)
+	}
+}
+
+func TestAuthorize(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	policy := Policy{"GET /customers/:id": CustomersRead}
+
+	tests := []struct {
+		name      string
+		principal *Principal
+		method    string
+		path      string
+		want      int
+	}{
+		{"granted", &Principal{Roles: []string{RoleAgent}}, "GET", "/customers/1", http.StatusOK},
+		{"missing permission", &Principal{Scopes: []Permission{ProductsRead}}, "GET", "/customers/1", http.StatusForbidden},
+		{"anonymous", nil, "GET", "/customers/1", http.StatusUnauthorized},
+		{"route without policy", &Principal{Roles: []string{RoleAdmin}}, "DELETE", "/customers/1", http.StatusForbidden},
+		{"unknown route", nil, "GET", "/nowhere", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := gin.New()
+			r.Use(func(c *gin.Context) {
+				if tt.principal != nil {
+					c.Request = c.Request.WithContext(WithPrincipal(c.Request.Context(), tt.principal))
+				}
+			}, Authorize(policy))
+			ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+			r.GET("/customers/:id", ok)
+			r.DELETE("/customers/:id", ok)
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(tt.method, tt.path, nil))
+			if w.Code != tt.want {
+				t.Errorf("status = %d, want %d: %s", w.Code, tt.want, w.Body)
+			}
+		})
+	}
+}
@@ -0,0 +1,257 @@
+package config
+
+import (
+	"fmt"
+	"log/slog"
+	"net/netip"
+	"strings"
+	"time"
+)
+
+// Config is the whole runtime configuration of the service.
+type Config struct {
+	HTTP      HTTPConfig
+	Log       LogConfig
+	DB        DBConfig
+	Kafka     KafkaConfig
+	CORS      CORSConfig
+	Auth      AuthConfig
+	RateLimit RateLimitConfig
+	Tracing   TracingConfig
+	Shutdown  ShutdownConfig
+	Features  FeatureConfig
+}
+
+type HTTPConfig struct {
+	Port         int
+	ReadTimeout  time.Duration
+	WriteTimeout time.Duration
+	IdleTimeout  time.Duration
+	// ExportTimeout replaces WriteTimeout for export downloads, which
+	// stream for as long as the table takes to read.
+	ExportTimeout time.Duration
+	// HealthTimeout bounds all readiness checks of one probe.
+	HealthTimeout time.Duration
+	// TrustedProxies are the IPs or CIDRs whose X-Forwarded-For is
+	// believed. With none, a client's IP is the connection's peer address.
+	TrustedProxies []string
+}
+
+type LogConfig struct {
+	Level slog.Level
+	// Format is json or text.
+	Format string
+}
+
+type DBConfig struct {
+	URI string
+	// MigrateOnStart applies pending migrations before serving.
+	MigrateOnStart  bool
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+	ConnMaxIdleTime time.Duration
+}
+
+type KafkaConfig struct {
+	Brokers []string
+	// PublishTopic is the topic written by POST /publish.
+	PublishTopic    string
+	ConsumerGroup   string
+	ConsumerTopics  []string
+	DeadLetterTopic string
+	MaxRetries      int
+	// OutboxMaxAttempts is how often the outbox relay tries a message
+	// before parking it.
+	OutboxMaxAttempts int
+}
+
+type CORSConfig struct {
+	AllowOrigins []string
+}
+
+type AuthConfig struct {
+	Enabled bool
+	// HS256Secret verifies HS256 tokens; JWKSFile holds the RS256 keys.
+	HS256Secret string
+	JWKSFile    string
+	Issuer      string
+	Audience    string
+	Leeway      time.Duration
+}
+
+// RateLimitConfig is the default token bucket per client; routes can
+// override it in code.
+type RateLimitConfig struct {
+	Enabled bool
+	RPS     float64
+	Burst   int
+	// IPRPS and IPBurst limit each IP before authentication, which bounds
+	// credential guessing.
+	IPRPS   float64
+	IPBurst int
+	// Quota caps the requests of each client per QuotaWindow; 0 turns the
+	// quota off.
+	Quota       int
+	QuotaWindow time.Duration
+}
+
+// TracingConfig selects where OpenTelemetry spans are exported.
+type TracingConfig struct {
+	// Exporter is none, stdout or otlp.
+	Exporter string
+	// OTLPEndpoint is the collector's OTLP/HTTP endpoint, e.g.
+	// http://otel-collector:4318. Empty falls back to the standard
+	// OTEL_EXPORTER_OTLP_* environment variables.
+	OTLPEndpoint string
+	SampleRatio  float64
+}
+
+// ShutdownConfig controls how the service stops on SIGTERM.
+type ShutdownConfig struct {
+	// Delay keeps serving after readiness starts failing, so load
+	// balancers stop routing to this replica before it stops listening.
+	Delay time.Duration
+	// Timeout bounds the whole shutdown, Delay included.
+	Timeout time.Duration
+}
+
+// FeatureConfig switches optional parts of the service on or off.
+type FeatureConfig struct {
+	Consumer        bool
+	OutboxRelay     bool
+	PublishEndpoint bool
+	Metrics         bool
+}
+
+// Error lists every problem found while loading the configuration so they
+// can all be fixed in one go.
+type Error struct {
+	Problems []string
+}
+
+func (e *Error) Error() string {
+	return "invalid configuration:\n  - " + strings.Join(e.Problems, "\n  - ")
+}
+
+func (e *Error) add(key, format string, args ...any) {
+	e.Problems = append(e.Problems, key+": "+fmt.Sprintf(format, args...))
+}
+
+func (e *Error) orNil() error {
+	if len(e.Problems) > 0 {
+		return e
+	}
+	return nil
+}
+
+// Validate checks values that are well-formed but do not make sense.
+func (c *Config) Validate() error {
+	errs := &Error{}
+	c.validateCommon(errs)
+
+	if c.HTTP.Port < 1 || c.HTTP.Port > 65535 {
+		errs.add("PORT", "must be between 1 and 65535, got %d", c.HTTP.Port)
+	}
+	if c.HTTP.ExportTimeout <= 0 {
+		errs.add("HTTP_EXPORT_TIMEOUT", "must be positive")
+	}
+	if c.HTTP.HealthTimeout <= 0 {
+		errs.add("HEALTH_CHECK_TIMEOUT", "must be positive")
+	}
+	for _, proxy := range c.HTTP.TrustedProxies {
+		if _, err := netip.ParsePrefix(proxy); err == nil {
+			continue
+		}
+		if _, err := netip.ParseAddr(proxy); err != nil {
+			errs.add("HTTP_TRUSTED_PROXIES", "%q is not an IP address or CIDR", proxy)
+		}
+	}
+	if len(c.Kafka.Brokers) == 0 {
+		errs.add("KAFKA_BROKERS", "at least one broker is required")
+	}
+	if c.Features.PublishEndpoint && c.Kafka.PublishTopic == "" {
+		errs.add("KAFKA_PUBLISH_TOPIC", "is required when FEATURE_PUBLISH_ENDPOINT is on")
+	}
+	if c.Features.Consumer {
+		if c.Kafka.ConsumerGroup == "" {
+			errs.add("KAFKA_CONSUMER_GROUP", "is required when FEATURE_CONSUMER is on")
+		}
+		if len(c.Kafka.ConsumerTopics) == 0 {
+			errs.add("KAFKA_CONSUMER_TOPICS", "is required when FEATURE_CONSUMER is on")
+		}
+	}
+	if c.Kafka.MaxRetries < 0 {
+		errs.add("KAFKA_CONSUMER_MAX_RETRIES", "must not be negative")
+	}
+	if c.Kafka.OutboxMaxAttempts < 1 {
+		errs.add("OUTBOX_MAX_ATTEMPTS", "must be at least 1")
+	}
+	if len(c.CORS.AllowOrigins) == 0 {
+		errs.add("CORS_ALLOW_ORIGINS", "at least one origin is required")
+	}
+	if c.Auth.Enabled && c.Auth.HS256Secret == "" && c.Auth.JWKSFile == "" {
+		errs.add("AUTH_HS256_SECRET", "AUTH_HS256_SECRET or AUTH_JWKS_FILE is required when AUTH_ENABLED is on")
+	}
+	if c.RateLimit.Enabled && c.RateLimit.RPS <= 0 {
+		errs.add("RATE_LIMIT_RPS", "must be positive")
+	}
+	if c.RateLimit.Enabled && c.RateLimit.Burst < 1 {
+		errs.add("RATE_LIMIT_BURST", "must be at least 1")
+	}
+	if c.RateLimit.Enabled && c.RateLimit.IPRPS <= 0 {
+		errs.add("RATE_LIMIT_IP_RPS", "must be positive")
+	}
+	if c.RateLimit.Enabled && c.RateLimit.IPBurst < 1 {
+		errs.add("RATE_LIMIT_IP_BURST", "must be at least 1")
+	}
+	if c.RateLimit.Enabled && c.RateLimit.Quota < 0 {
+		errs.add("RATE_LIMIT_QUOTA", "must not be negative")
+	}
+	if c.RateLimit.Enabled && c.RateLimit.Quota > 0 && c.RateLimit.QuotaWindow < time.Minute {
+		errs.add("RATE_LIMIT_QUOTA_WINDOW", "must be at least 1m")
+	}
+	if c.Auth.HS256Secret != "" && len(c.Auth.HS256Secret) < 32 {
+		errs.add("AUTH_HS256_SECRET", "must be at least 32 bytes")
+	}
+	if c.Shutdown.Delay < 0 {
+		errs.add("SHUTDOWN_DELAY", "must not be negative")
+	}
+	if c.Shutdown.Timeout <= c.Shutdown.Delay {
+		errs.add("SHUTDOWN_TIMEOUT", "must be longer than SHUTDOWN_DELAY (%s)", c.Shutdown.Delay)
+	}
+	switch c.Tracing.Exporter {
+	case "none", "stdout", "otlp":
+	default:
+		errs.add("TRACING_EXPORTER", "must be none, stdout or otlp, got %q", c.Tracing.Exporter)
+	}
+	if c.Tracing.SampleRatio < 0 || c.Tracing.SampleRatio > 1 {
+		errs.add("TRACING_SAMPLE_RATIO", "must be between 0 and 1")
+	}
+
+	return errs.orNil()
+}
+
+// ValidateCommand checks only what the migrate and import commands use,
+// the database and log settings, so they run without the server's auth,
+// Kafka and HTTP settings.
+func (c *Config) ValidateCommand() error {
+	errs := &Error{}
+	c.validateCommon(errs)
+	return errs.orNil()
+}
+
+func (c *Config) validateCommon(errs *Error) {
+	if c.DB.URI == "" {
+		errs.add("DATABASE_URI", "is required")
+	}
+	if c.DB.MaxOpenConns < 0 {
+		errs.add("DB_MAX_OPEN_CONNS", "must not be negative")
+	}
+	if c.DB.MaxOpenConns > 0 && c.DB.MaxIdleConns > c.DB.MaxOpenConns {
+		errs.add("DB_MAX_IDLE_CONNS", "must not exceed DB_MAX_OPEN_CONNS (%d)", c.DB.MaxOpenConns)
+	}
+	if c.Log.Format != "json" && c.Log.Format != "text" {
+		errs.add("LOG_FORMAT", "must be json or text, got %q", c.Log.Format)
+	}
+}
@@ -0,0 +1,227 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"io/fs"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/joho/godotenv"
+)
+
+// setting binds one configuration value to its environment variable. The
+// matching command-line flag is the lower-cased key with dashes, e.g.
+// DATABASE_URI becomes -database-uri.
+type setting struct {
+	key   string
+	def   string
+	usage string
+	apply func(c *Config, v string) error
+}
+
+var settings = []setting{
+	{"PORT", "8080", "HTTP listen port", intVar(func(c *Config) *int { return &c.HTTP.Port })},
+	{"HTTP_READ_TIMEOUT", "15s", "maximum duration for reading a request", durationVar(func(c *Config) *time.Duration { return &c.HTTP.ReadTimeout })},
+	{"HTTP_WRITE_TIMEOUT", "30s", "maximum duration for writing a response", durationVar(func(c *Config) *time.Duration { return &c.HTTP.WriteTimeout })},
+	{"HTTP_IDLE_TIMEOUT", "60s", "keep-alive idle timeout", durationVar(func(c *Config) *time.Duration { return &c.HTTP.IdleTimeout })},
+	{"HTTP_EXPORT_TIMEOUT", "30m", "maximum duration for streaming one export", durationVar(func(c *Config) *time.Duration { return &c.HTTP.ExportTimeout })},
+	{"HEALTH_CHECK_TIMEOUT", "2s", "time limit for the readiness checks of one probe", durationVar(func(c *Config) *time.Duration { return &c.HTTP.HealthTimeout })},
+	{"HTTP_TRUSTED_PROXIES", "", "comma separated proxy IPs or CIDRs whose X-Forwarded-For is trusted", listVar(func(c *Config) *[]string { return &c.HTTP.TrustedProxies })},
+
+	{"LOG_LEVEL", "info", "minimum log level: debug, info, warn or error", levelVar(func(c *Config) *slog.Level { return &c.Log.Level })},
+	{"LOG_FORMAT", "json", "log output format: json or text", stringVar(func(c *Config) *string { return &c.Log.Format })},
+
+	{"DATABASE_URI", "", "PostgreSQL DSN", stringVar(func(c *Config) *string { return &c.DB.URI })},
+	{"DB_MAX_OPEN_CONNS", "25", "maximum open connections (0 = unlimited)", intVar(func(c *Config) *int { return &c.DB.MaxOpenConns })},
+	{"DB_MAX_IDLE_CONNS", "10", "maximum idle connections", intVar(func(c *Config) *int { return &c.DB.MaxIdleConns })},
+	{"DB_CONN_MAX_LIFETIME", "30m", "maximum lifetime of a connection", durationVar(func(c *Config) *time.Duration { return &c.DB.ConnMaxLifetime })},
+	{"DB_CONN_MAX_IDLE_TIME", "5m", "maximum idle time of a connection", durationVar(func(c *Config) *time.Duration { return &c.DB.ConnMaxIdleTime })},
+	{"DB_MIGRATE_ON_START", "true", "apply pending migrations at startup", boolVar(func(c *Config) *bool { return &c.DB.MigrateOnStart })},
+
+	{"KAFKA_BROKERS", "kafka:9092", "comma separated Kafka brokers", listVar(func(c *Config) *[]string { return &c.Kafka.Brokers })},
+	{"KAFKA_PUBLISH_TOPIC", "my-topic", "topic written by POST /publish", stringVar(func(c *Config) *string { return &c.Kafka.PublishTopic })},
+	{"KAFKA_CONSUMER_GROUP", "my-group", "consumer group id", stringVar(func(c *Config) *string { return &c.Kafka.ConsumerGroup })},
+	{"KAFKA_CONSUMER_TOPICS", "my-topic", "comma separated topics to consume", listVar(func(c *Config) *[]string { return &c.Kafka.ConsumerTopics })},
+	{"KAFKA_DEAD_LETTER_TOPIC", "my-topic.dlq", "topic for messages that keep failing (empty = drop)", stringVar(func(c *Config) *string { return &c.Kafka.DeadLetterTopic })},
+	{"KAFKA_CONSUMER_MAX_RETRIES", "3", "handler retries before dead-lettering", intVar(func(c *Config) *int { return &c.Kafka.MaxRetries })},
+	{"OUTBOX_MAX_ATTEMPTS", "10", "outbox relay attempts before a message is parked", intVar(func(c *Config) *int { return &c.Kafka.OutboxMaxAttempts })},
+
+	{"CORS_ALLOW_ORIGINS", "*", "comma separated allowed origins", listVar(func(c *Config) *[]string { return &c.CORS.AllowOrigins })},
+
+	{"AUTH_ENABLED", "true", "require a bearer token on API routes", boolVar(func(c *Config) *bool { return &c.Auth.Enabled })},
+	{"AUTH_HS256_SECRET", "", "shared secret for HS256 tokens", stringVar(func(c *Config) *string { return &c.Auth.HS256Secret })},
+	{"AUTH_JWKS_FILE", "", "JWKS file with the RS256 public keys", stringVar(func(c *Config) *string { return &c.Auth.JWKSFile })},
+	{"AUTH_ISSUER", "", "required iss claim (empty = any)", stringVar(func(c *Config) *string { return &c.Auth.Issuer })},
+	{"AUTH_AUDIENCE", "", "required aud claim (empty = any)", stringVar(func(c *Config) *string { return &c.Auth.Audience })},
+	{"AUTH_LEEWAY", "30s", "allowed clock skew for exp/nbf/iat", durationVar(func(c *Config) *time.Duration { return &c.Auth.Leeway })},
+
+	{"RATE_LIMIT_ENABLED", "true", "limit requests per client", boolVar(func(c *Config) *bool { return &c.RateLimit.Enabled })},
+	{"RATE_LIMIT_RPS", "10", "sustained requests per second per client", floatVar(func(c *Config) *float64 { return &c.RateLimit.RPS })},
+	{"RATE_LIMIT_BURST", "20", "requests a client may burst above the rate", intVar(func(c *Config) *int { return &c.RateLimit.Burst })},
+	{"RATE_LIMIT_IP_RPS", "50", "sustained requests per second per IP, checked before authentication", floatVar(func(c *Config) *float64 { return &c.RateLimit.IPRPS })},
+	{"RATE_LIMIT_IP_BURST", "100", "requests an IP may burst above its rate", intVar(func(c *Config) *int { return &c.RateLimit.IPBurst })},
+	{"RATE_LIMIT_QUOTA", "100000", "requests per client per quota window (0 = no quota)", intVar(func(c *Config) *int { return &c.RateLimit.Quota })},
+	{"RATE_LIMIT_QUOTA_WINDOW", "24h", "length of the quota window", durationVar(func(c *Config) *time.Duration { return &c.RateLimit.QuotaWindow })},
+
+	{"TRACING_EXPORTER", "none", "where spans go: none, stdout or otlp", stringVar(func(c *Config) *string { return &c.Tracing.Exporter })},
+	{"TRACING_OTLP_ENDPOINT", "", "OTLP/HTTP collector endpoint (empty = OTEL_EXPORTER_OTLP_ENDPOINT)", stringVar(func(c *Config) *string { return &c.Tracing.OTLPEndpoint })},
+	{"TRACING_SAMPLE_RATIO", "1", "fraction of new traces that are sampled", floatVar(func(c *Config) *float64 { return &c.Tracing.SampleRatio })},
+
+	{"SHUTDOWN_DELAY", "0s", "keep serving this long after readiness fails on SIGTERM", durationVar(func(c *Config) *time.Duration { return &c.Shutdown.Delay })},
+	{"SHUTDOWN_TIMEOUT", "30s", "deadline for draining requests, stopping workers and closing connections", durationVar(func(c *Config) *time.Duration { return &c.Shutdown.Timeout })},
+
+	{"FEATURE_CONSUMER", "true", "run the Kafka consumer", boolVar(func(c *Config) *bool { return &c.Features.Consumer })},
+	{"FEATURE_OUTBOX_RELAY", "true", "run the outbox relay", boolVar(func(c *Config) *bool { return &c.Features.OutboxRelay })},
+	{"FEATURE_PUBLISH_ENDPOINT", "true", "expose POST /publish", boolVar(func(c *Config) *bool { return &c.Features.PublishEndpoint })},
+	{"FEATURE_METRICS", "true", "expose GET /metrics", boolVar(func(c *Config) *bool { return &c.Features.Metrics })},
+}
+
+// Load builds the configuration from, in increasing precedence: defaults,
+// the env file named by -config (default .env, optional unless set
+// explicitly), environment variables and command-line flags. It returns the
+// arguments left after the flags. When arguments are left a command runs
+// instead of the server, and only the settings commands use are validated.
+func Load(args []string) (*Config, []string, error) {
+	fset := flag.NewFlagSet("customer-api", flag.ContinueOnError)
+	file := fset.String("config", ".env", "env file to read settings from")
+	flags := make(map[string]*string, len(settings))
+	for _, s := range settings {
+		flags[s.key] = fset.String(flagName(s.key), s.def, s.usage+" ($"+s.key+")")
+	}
+	if err := fset.Parse(args); err != nil {
+		return nil, nil, err
+	}
+
+	explicit := make(map[string]bool)
+	fset.Visit(func(f *flag.Flag) { explicit[f.Name] = true })
+
+	fileValues, err := godotenv.Read(*file)
+	if err != nil {
+		if !errors.Is(err, fs.ErrNotExist) || explicit["config"] {
+			return nil, nil, &Error{Problems: []string{"-config: " + err.Error()}}
+		}
+		fileValues = map[string]string{}
+	}
+
+	cfg := &Config{}
+	errs := &Error{}
+	failed := make(map[string]bool)
+	for _, s := range settings {
+		v := s.def
+		if fv, ok := fileValues[s.key]; ok {
+			v = fv
+		}
+		if ev, ok := os.LookupEnv(s.key); ok {
+			v = ev
+		}
+		if explicit[flagName(s.key)] {
+			v = *flags[s.key]
+		}
+		if err := s.apply(cfg, strings.TrimSpace(v)); err != nil {
+			errs.add(s.key, "%v", err)
+			failed[s.key] = true
+		}
+	}
+
+	validate := cfg.Validate
+	if fset.NArg() > 0 {
+		validate = cfg.ValidateCommand
+	}
+	var verr *Error
+	if errors.As(validate(), &verr) {
+		for _, p := range verr.Problems {
+			// a value that did not parse is already reported
+			key, _, _ := strings.Cut(p, ":")
+			if !failed[key] {
+				errs.Problems = append(errs.Problems, p)
+			}
+		}
+	}
+	if len(errs.Problems) > 0 {
+		return nil, nil, errs
+	}
+	return cfg, fset.Args(), nil
+}
+
+func flagName(key string) string {
+	return strings.ReplaceAll(strings.ToLower(key), "_", "-")
+}
+
+func stringVar(field func(*Config) *string) func(*Config, string) error {
+	return func(c *Config, v string) error {
+		*field(c) = v
+		return nil
+	}
+}
+
+func intVar(field func(*Config) *int) func(*Config, string) error {
+	return func(c *Config, v string) error {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return errors.New("must be an integer, got " + strconv.Quote(v))
+		}
+		*field(c) = n
+		return nil
+	}
+}
+
+func floatVar(field func(*Config) *float64) func(*Config, string) error {
+	return func(c *Config, v string) error {
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return errors.New("must be a number, got " + strconv.Quote(v))
+		}
+		*field(c) = f
+		return nil
+	}
+}
+
+func levelVar(field func(*Config) *slog.Level) func(*Config, string) error {
+	return func(c *Config, v string) error {
+		var l slog.Level
+		if err := l.UnmarshalText([]byte(v)); err != nil {
+			return errors.New("must be debug, info, warn or error, got " + strconv.Quote(v))
+		}
+		*field(c) = l
+		return nil
+	}
+}
+
+func boolVar(field func(*Config) *bool) func(*Config, string) error {
+	return func(c *Config, v string) error {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return errors.New("must be true or false, got " + strconv.Quote(v))
+		}
+		*field(c) = b
+		return nil
+	}
+}
+
+func durationVar(field func(*Config) *time.Duration) func(*Config, string) error {
+	return func(c *Config, v string) error {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return errors.New("must be a duration like 30s or 5m, got " + strconv.Quote(v))
+		}
+		*field(c) = d
+		return nil
+	}
+}
+
+func listVar(field func(*Config) *[]string) func(*Config, string) error {
+	return func(c *Config, v string) error {
+		var list []string
+		for _, item := range strings.Split(v, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				list = append(list, item)
+			}
+		}
+		*field(c) = list
+		return nil
+	}
+}
@@ -0,0 +1,220 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// validEnv is the least a server needs beyond the defaults.
+var validEnv = map[string]string{
+	"DATABASE_URI":      "postgres://localhost/customers",
+	"AUTH_HS256_SECRET": strings.Repeat("s", 32),
+}
+
+// setEnv clears every setting from the environment, then sets env.
+func setEnv(t *testing.T, env map[string]string) {
+	t.Helper()
+	for _, s := range settings {
+		if v, ok := os.LookupEnv(s.key); ok {
+			t.Setenv(s.key, v)
+			os.Unsetenv(s.key)
+		}
+	}
+	for k, v := range env {
+		t.Setenv(k, v)
+	}
+}
+
+func writeEnvFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "test.env")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadDefaults(t *testing.T) {
+	setEnv(t, validEnv)
+	t.Chdir(t.TempDir())
+
+	cfg, args, err := Load(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(args) != 0 {
+		t.Errorf("args = %v, want none", args)
+	}
+	if cfg.HTTP.Port != 8080 || cfg.HTTP.WriteTimeout != 30*time.Second || !cfg.Auth.Enabled {
+		t.Errorf("defaults not applied: %+v", cfg)
+	}
+	if !slices.Equal(cfg.Kafka.Brokers, []string{"kafka:9092"}) {
+		t.Errorf("Kafka.Brokers = %v", cfg.Kafka.Brokers)
+	}
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	file := writeEnvFile(t, "PORT=9001\nLOG_LEVEL=debug\nKAFKA_BROKERS=file:9092\n")
+	setEnv(t, validEnv)
+	t.Setenv("PORT", "9002")
+	t.Setenv("KAFKA_BROKERS", " a:9092 , ,b:9092 ")
+
+	cfg, args, err := Load([]string{"-config", file, "-port", "9003", "migrate", "up"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.HTTP.Port != 9003 {
+		t.Errorf("Port = %d, want the flag's 9003", cfg.HTTP.Port)
+	}
+	if cfg.Log.Level.String() != "DEBUG" {
+		t.Errorf("Log.Level = %v, want the file's debug", cfg.Log.Level)
+	}
+	if !slices.Equal(cfg.Kafka.Brokers, []string{"a:9092", "b:9092"}) {
+		t.Errorf("Kafka.Brokers = %v, want the environment's", cfg.Kafka.Brokers)
+	}
+	if !slices.Equal(args, []string{"migrate", "up"}) {
+		t.Errorf("args = %v", args)
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		env  map[string]string
+		args []string
+		// want are the keys of the expected problems.
+		want []string
+	}{
+		{
+			name: "unparsable values are each reported once",
+			env:  map[string]string{"PORT": "eighty", "RATE_LIMIT_RPS": "fast", "AUTH_ENABLED": "maybe"},
+			want: []string{"PORT", "RATE_LIMIT_RPS", "AUTH_ENABLED"},
+		},
+		{
+			name: "missing database and auth secret",
+			env:  map[string]string{"DATABASE_URI": "", "AUTH_HS256_SECRET": ""},
+			want: []string{"DATABASE_URI", "AUTH_HS256_SECRET"},
+		},
+		{
+			name: "commands need only the database",
+			env:  map[string]string{"DATABASE_URI": "", "AUTH_HS256_SECRET": "", "KAFKA_BROKERS": "", "PORT": "0"},
+			args: []string{"migrate", "status"},
+			want: []string{"DATABASE_URI"},
+		},
+		{
+			name: "explicit env file must exist",
+			args: []string{"-config", "/does/not/exist.env"},
+			want: []string{"-config"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setEnv(t, validEnv)
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+			t.Chdir(t.TempDir())
+
+			_, _, err := Load(tt.args)
+			var cerr *Error
+			if !errors.As(err, &cerr) {
+				t.Fatalf("error = %v, want *Error", err)
+			}
+			if got := problemKeys(cerr); !slices.Equal(got, sorted(tt.want)) {
+				t.Errorf("problems = %q, want keys %v", cerr.Problems, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadCommandWithMinimalEnvironment(t *testing.T) {
+	setEnv(t, map[string]string{
+		"DATABASE_URI":  "postgres://localhost/customers",
+		"KAFKA_BROKERS": "",
+		"PORT":          "0",
+	})
+	t.Chdir(t.TempDir())
+
+	for _, args := range [][]string{{"migrate", "up"}, {"import", "customers.csv"}} {
+		if _, _, err := Load(args); err != nil {
+			t.Errorf("Load(%q) = %v, want nil", args, err)
+		}
+	}
+	if _, _, err := Load(nil); err == nil {
+		t.Error("Load(nil) = nil, want the server settings rejected")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(*Config)
+		want   []string
+	}{
+		{"valid", func(*Config) {}, nil},
+		{"port out of range", func(c *Config) { c.HTTP.Port = 70000 }, []string{"PORT"}},
+		{"idle above open connections", func(c *Config) { c.DB.MaxOpenConns, c.DB.MaxIdleConns = 5, 6 }, []string{"DB_MAX_IDLE_CONNS"}},
+		{"unlimited open connections", func(c *Config) { c.DB.MaxOpenConns, c.DB.MaxIdleConns = 0, 50 }, nil},
+		{"no brokers", func(c *Config) { c.Kafka.Brokers = nil }, []string{"KAFKA_BROKERS"}},
+		{"consumer without group", func(c *Config) { c.Kafka.ConsumerGroup = "" }, []string{"KAFKA_CONSUMER_GROUP"}},
+		{"consumer off without group", func(c *Config) { c.Features.Consumer, c.Kafka.ConsumerGroup = false, "" }, nil},
+		{"short secret", func(c *Config) { c.Auth.HS256Secret = "short" }, []string{"AUTH_HS256_SECRET"}},
+		{"jwks instead of secret", func(c *Config) { c.Auth.HS256Secret, c.Auth.JWKSFile = "", "keys.json" }, nil},
+		{"auth off without keys", func(c *Config) { c.Auth.Enabled, c.Auth.HS256Secret = false, "" }, nil},
+		{"rate limit without burst", func(c *Config) { c.RateLimit.Burst = 0 }, []string{"RATE_LIMIT_BURST"}},
+		{"ip rate limit without burst", func(c *Config) { c.RateLimit.IPBurst = 0 }, []string{"RATE_LIMIT_IP_BURST"}},
+		{"quota window too short", func(c *Config) { c.RateLimit.QuotaWindow = time.Second }, []string{"RATE_LIMIT_QUOTA_WINDOW"}},
+		{"no quota", func(c *Config) { c.RateLimit.Quota, c.RateLimit.QuotaWindow = 0, 0 }, nil},
+		{"trusted proxies", func(c *Config) { c.HTTP.TrustedProxies = []string{"10.0.0.1", "10.0.0.0/8", "fd00::/8"} }, nil},
+		{"trusted proxy hostname", func(c *Config) { c.HTTP.TrustedProxies = []string{"10.0.0.1", "proxy.local"} }, []string{"HTTP_TRUSTED_PROXIES"}},
+		{"shutdown delay past timeout", func(c *Config) { c.Shutdown.Delay = time.Minute }, []string{"SHUTDOWN_TIMEOUT"}},
+		{"unknown exporter", func(c *Config) { c.Tracing.Exporter = "jaeger" }, []string{"TRACING_EXPORTER"}},
+		{"sample ratio above one", func(c *Config) { c.Tracing.SampleRatio = 1.5 }, []string{"TRACING_SAMPLE_RATIO"}},
+		{"unknown log format", func(c *Config) { c.Log.Format = "xml" }, []string{"LOG_FORMAT"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setEnv(t, validEnv)
+			t.Chdir(t.TempDir())
+			cfg, _, err := Load(nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			tt.modify(cfg)
+
+			err = cfg.Validate()
+			if tt.want == nil {
+				if err != nil {
+					t.Fatalf("Validate() = %v, want nil", err)
+				}
+				return
+			}
+			var cerr *Error
+			if !errors.As(err, &cerr) {
+				t.Fatalf("Validate() = %v, want *Error", err)
+			}
+			if got := problemKeys(cerr); !slices.Equal(got, sorted(tt.want)) {
+				t.Errorf("problems = %q, want keys %v", cerr.Problems, tt.want)
+			}
+		})
+	}
+}
+
+func problemKeys(e *Error) []string {
+	keys := make([]string, len(e.Problems))
+	for i, p := range e.Problems {
+		keys[i], _, _ = strings.Cut(p, ":")
+	}
+	return sorted(keys)
+}
+
+func sorted(s []string) []string {
+	s = slices.Clone(s)
+	slices.Sort(s)
+	return s
+}
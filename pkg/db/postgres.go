@@ -1,19 +1,27 @@
 package db
 
 import (
-	"log"
-	"os"
+	"customer-api/pkg/config"
+	"customer-api/pkg/logging"
 
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 )
 
-func NewPostgresDB() *gorm.DB {
-	dsn := os.Getenv("DATABASE_URI")
-	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+// NewPostgresDB opens the database and sizes its connection pool.
+func NewPostgresDB(cfg config.DBConfig) (*gorm.DB, error) {
+	db, err := gorm.Open(postgres.Open(cfg.URI), &gorm.Config{Logger: logging.GormLogger{}, TranslateError: true})
+	if err != nil {
+		return nil, err
+	}
 
+	sqlDB, err := db.DB()
 	if err != nil {
-		log.Fatalf("Failed to connect database: %v", err)
+		return nil, err
 	}
-	return db
+	sqlDB.SetMaxOpenConns(cfg.MaxOpenConns)
+	sqlDB.SetMaxIdleConns(cfg.MaxIdleConns)
+	sqlDB.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+	sqlDB.SetConnMaxIdleTime(cfg.ConnMaxIdleTime)
+	return db, nil
 }
@@ -0,0 +1,71 @@
+package event
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Version is the schema version of the Event envelope. Bump it on any
+// breaking change to the envelope or to an event's data payload.
+const Version = 1
+
+const Source = "customer-api"
+
+const (
+	CustomerCreated = "customer.created"
+	CustomerUpdated = "customer.updated"
+	CustomerDeleted = "customer.deleted"
+	// CustomerErased asks subscribers to erase their copies of the
+	// customer's personal data.
+	CustomerErased = "customer.erased"
+
+	FeedbackSubmitted = "feedback.submitted"
+	FeedbackUpdated   = "feedback.updated"
+	FeedbackDeleted   = "feedback.deleted"
+)
+
+// Event is the versioned JSON envelope published for every domain change.
+type Event struct {
+	ID          uuid.UUID `json:"id"`
+	Type        string    `json:"type"`
+	Version     int       `json:"version"`
+	Source      string    `json:"source"`
+	AggregateID uuid.UUID `json:"aggregateId"`
+	// Actor is the authenticated subject that caused the change, if any.
+	Actor      string          `json:"actor,omitempty"`
+	OccurredAt time.Time       `json:"occurredAt"`
+	Data       json.RawMessage `json:"data"`
+}
+
+// Publisher delivers events to downstream subscribers.
+type Publisher interface {
+	Publish(ctx context.Context, events ...Event) error
+}
+
+// New builds an event of type typ about the entity aggregateID.
+func New(typ string, aggregateID uuid.UUID, data any) (Event, error) {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return Event{}, err
+	}
+	return Event{
+		ID:          uuid.New(),
+		Type:        typ,
+		Version:     Version,
+		Source:      Source,
+		AggregateID: aggregateID,
+		OccurredAt:  time.Now().UTC(),
+		Data:        raw,
+	}, nil
+}
+
+// Topic is the topic an event is published to: one per aggregate,
+// e.g. customer.created goes to customer.events.
+func (e Event) Topic() string {
+	aggregate, _, _ := strings.Cut(e.Type, ".")
+	return aggregate + ".events"
+}
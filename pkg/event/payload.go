@@ -0,0 +1,58 @@
+package event
+
+import (
+	"customer-api/pkg/model"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// CustomerData is the payload of customer.created and customer.updated.
+type CustomerData struct {
+	ID        uuid.UUID `json:"id"`
+	Name      string    `json:"name"`
+	Email     string    `json:"email"`
+	Phone     string    `json:"phone"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// FeedbackData is the payload of feedback.submitted and feedback.updated.
+type FeedbackData struct {
+	ID         uuid.UUID `json:"id"`
+	CustomerID uuid.UUID `json:"customerId"`
+	ProductID  uuid.UUID `json:"productId"`
+	Rating     int       `json:"rating"`
+	Comment    string    `json:"comment"`
+	CreatedAt  time.Time `json:"createdAt"`
+	UpdatedAt  time.Time `json:"updatedAt"`
+}
+
+// DeletedData is the payload of every *.deleted event and of
+// customer.erased.
+type DeletedData struct {
+	ID uuid.UUID `json:"id"`
+}
+
+func NewCustomerData(c *model.Customer) CustomerData {
+	return CustomerData{
+		ID:        c.ID,
+		Name:      c.Name,
+		Email:     c.Email,
+		Phone:     c.Phone,
+		CreatedAt: c.CreatedAt,
+		UpdatedAt: c.UpdatedAt,
+	}
+}
+
+func NewFeedbackData(f *model.Feedback) FeedbackData {
+	return FeedbackData{
+		ID:         f.ID,
+		CustomerID: f.CustomerID,
+		ProductID:  f.ProductID,
+		Rating:     f.Rating,
+		Comment:    f.Comment,
+		CreatedAt:  f.CreatedAt,
+		UpdatedAt:  f.UpdatedAt,
+	}
+}
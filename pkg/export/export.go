@@ -0,0 +1,267 @@
+// Package export writes rows as CSV, NDJSON or XLSX one at a time, so a
+// caller can stream a table of any size without holding it in memory.
+package export
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+)
+
+type Format string
+
+const (
+	CSV    Format = "csv"
+	NDJSON Format = "ndjson"
+	XLSX   Format = "xlsx"
+)
+
+// Formats lists the supported formats.
+var Formats = []string{string(CSV), string(NDJSON), string(XLSX)}
+
+// ErrTooManyRows is returned by an XLSX writer past the sheet's row limit.
+var ErrTooManyRows = fmt.Errorf("export: xlsx holds at most %d rows", excelize.TotalRows-1)
+
+// ParseFormat accepts one of Formats; empty means CSV.
+func ParseFormat(s string) (Format, bool) {
+	switch f := Format(s); f {
+	case "":
+		return CSV, true
+	case CSV, NDJSON, XLSX:
+		return f, true
+	default:
+		return "", false
+	}
+}
+
+// ContentType is the media type of a file in format f.
+func (f Format) ContentType() string {
+	switch f {
+	case NDJSON:
+		return "application/x-ndjson"
+	case XLSX:
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	default:
+		return "text/csv; charset=utf-8"
+	}
+}
+
+// Column is one field of an exported row. Value returns a string, number,
+// bool, time.Time or nil; anything else is written with fmt.
+type Column[T any] struct {
+	Name  string
+	Value func(*T) any
+}
+
+// Writer writes rows of T. Close finishes the file and Abort drops it;
+// one of them must be called. Neither closes the underlying io.Writer.
+type Writer[T any] interface {
+	Write(row *T) error
+	Close() error
+	Abort()
+}
+
+// NewWriter starts a file of the given columns in format f on w. CSV and
+// NDJSON rows reach w as they are written; an XLSX file is only written to
+// w by Close, the rows before that spill to a temporary file.
+func NewWriter[T any](w io.Writer, f Format, cols []Column[T]) (Writer[T], error) {
+	switch f {
+	case CSV:
+		cw := csv.NewWriter(w)
+		header := make([]string, len(cols))
+		for i, c := range cols {
+			header[i] = c.Name
+		}
+		if err := cw.Write(header); err != nil {
+			return nil, err
+		}
+		return &csvWriter[T]{w: cw, cols: cols, record: make([]string, len(cols))}, nil
+	case NDJSON:
+		return &ndjsonWriter[T]{w: bufio.NewWriter(w), cols: cols}, nil
+	case XLSX:
+		return newXLSXWriter(w, cols)
+	default:
+		return nil, fmt.Errorf("export: unknown format %q", f)
+	}
+}
+
+// value reads col from row, with times in UTC.
+func value[T any](col Column[T], row *T) any {
+	v := col.Value(row)
+	if t, ok := v.(time.Time); ok {
+		return t.UTC()
+	}
+	return v
+}
+
+type csvWriter[T any] struct {
+	w      *csv.Writer
+	cols   []Column[T]
+	record []string
+}
+
+func (c *csvWriter[T]) Write(row *T) error {
+	for i, col := range c.cols {
+		c.record[i] = csvText(value(col, row))
+	}
+	return c.w.Write(c.record)
+}
+
+func (c *csvWriter[T]) Close() error {
+	c.w.Flush()
+	return c.w.Error()
+}
+
+func (c *csvWriter[T]) Abort() {}
+
+// csvText formats v for a CSV cell. Text that a spreadsheet would read as
+// a formula is prefixed with a quote so opening the file cannot run it.
+func csvText(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		if isFormula(v) {
+			return "'" + v
+		}
+		return v
+	case int:
+		return strconv.Itoa(v)
+	case bool:
+		return strconv.FormatBool(v)
+	case time.Time:
+		return v.Format(time.RFC3339)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// isFormula reports whether a spreadsheet could evaluate s: it starts with
+// =, +, -, @, a tab or a carriage return. Only a cell that is a plain
+// number, like -12.5 or +66812345678, is left alone; a formatted phone
+// number such as +66 81 234 5678 is escaped too.
+func isFormula(s string) bool {
+	if s == "" || !strings.ContainsRune("=+-@\t\r", rune(s[0])) {
+		return false
+	}
+	_, err := strconv.ParseFloat(s, 64)
+	return err != nil
+}
+
+type ndjsonWriter[T any] struct {
+	w    *bufio.Writer
+	cols []Column[T]
+}
+
+// Write encodes row as an object with the columns in order.
+func (n *ndjsonWriter[T]) Write(row *T) error {
+	n.w.WriteByte('{')
+	for i, col := range n.cols {
+		if i > 0 {
+			n.w.WriteByte(',')
+		}
+		key, _ := json.Marshal(col.Name)
+		n.w.Write(key)
+		n.w.WriteByte(':')
+		v, err := json.Marshal(value(col, row))
+		if err != nil {
+			return err
+		}
+		n.w.Write(v)
+	}
+	_, err := n.w.WriteString("}\n")
+	return err
+}
+
+func (n *ndjsonWriter[T]) Close() error {
+	return n.w.Flush()
+}
+
+func (n *ndjsonWriter[T]) Abort() {}
+
+type xlsxWriter[T any] struct {
+	out  io.Writer
+	file *excelize.File
+	sw   *excelize.StreamWriter
+	cols []Column[T]
+	row  int
+	vals []any
+	// timeStyle shows times to the second
+	timeStyle int
+}
+
+const xlsxSheet = "Sheet1"
+
+func newXLSXWriter[T any](w io.Writer, cols []Column[T]) (*xlsxWriter[T], error) {
+	f := excelize.NewFile()
+	sw, err := f.NewStreamWriter(xlsxSheet)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	timeFormat := "yyyy-mm-dd hh:mm:ss"
+	timeStyle, err := f.NewStyle(&excelize.Style{CustomNumFmt: &timeFormat})
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	x := &xlsxWriter[T]{out: w, file: f, sw: sw, cols: cols, row: 1, vals: make([]any, len(cols)), timeStyle: timeStyle}
+	for i, c := range cols {
+		x.vals[i] = c.Name
+	}
+	if err := x.setRow(); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return x, nil
+}
+
+func (x *xlsxWriter[T]) Write(row *T) error {
+	if x.row > excelize.TotalRows {
+		return ErrTooManyRows
+	}
+	for i, col := range x.cols {
+		v := value(col, row)
+		if _, ok := v.(time.Time); ok {
+			v = excelize.Cell{StyleID: x.timeStyle, Value: v}
+		}
+		x.vals[i] = v
+	}
+	return x.setRow()
+}
+
+func (x *xlsxWriter[T]) setRow() error {
+	cell, err := excelize.CoordinatesToCellName(1, x.row)
+	if err != nil {
+		return err
+	}
+	if err := x.sw.SetRow(cell, x.vals); err != nil {
+		return err
+	}
+	x.row++
+	return nil
+}
+
+// Close assembles the workbook and writes it out.
+func (x *xlsxWriter[T]) Close() error {
+	err := x.sw.Flush()
+	if err == nil {
+		err = x.file.Write(x.out)
+	}
+	return errors.Join(err, x.file.Close())
+}
+
+// Abort removes the temporary files without writing anything.
+func (x *xlsxWriter[T]) Abort() {
+	x.file.Close()
+}
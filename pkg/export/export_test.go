@@ -0,0 +1,48 @@
+package export
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCSVText(t *testing.T) {
+	tests := []struct {
+		in   any
+		want string
+	}{
+		{nil, ""},
+		{"", ""},
+		{"Alice", "Alice"},
+		{"a=b", "a=b"},
+		{42, "42"},
+		{true, "true"},
+		{time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC), "2026-01-02T03:04:05Z"},
+
+		// plain numbers are data
+		{"+66812345678", "+66812345678"},
+		{"-12.5", "-12.5"},
+		{"+1e3", "+1e3"},
+
+		// formulas are escaped
+		{"=1+1", "'=1+1"},
+		{"@SUM(A1)", "'@SUM(A1)"},
+		{"+SUM(A1:A2)", "'+SUM(A1:A2)"},
+		{"-1+cmd|' /C calc'!A0", "'-1+cmd|' /C calc'!A0"},
+		{"-2+3", "'-2+3"},
+		{"-2-3", "'-2-3"},
+		{"+1+1", "'+1+1"},
+		{"+66 81 234 5678", "'+66 81 234 5678"},
+		{"+1 (555) 123-4567", "'+1 (555) 123-4567"},
+		{"-", "'-"},
+		{"+", "'+"},
+		{"+(1)", "'+(1)"},
+		{"--1", "'--1"},
+		{"\t=1", "'\t=1"},
+		{"\r=1", "'\r=1"},
+	}
+	for _, tt := range tests {
+		if got := csvText(tt.in); got != tt.want {
+			t.Errorf("csvText(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package handler
+
+import (
+	"customer-api/pkg/service"
+	"customer-api/pkg/validation"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+)
+
+type APIKeyHandler struct {
+	svc      service.APIKeyService
+	validate *validator.Validate
+}
+
+func NewAPIKeyHandler(svc service.APIKeyService) *APIKeyHandler {
+	return &APIKeyHandler{
+		svc:      svc,
+		validate: validation.New(),
+	}
+}
+
+// สร้าง api key ใหม่ (key จะแสดงแค่ครั้งเดียว)
+func (h *APIKeyHandler) CreateAPIKey(c *gin.Context) {
+	var req service.CreateAPIKeyRequest
+	if !bindJSON(c, h.validate, &req) {
+		return
+	}
+
+	created, err := h.svc.Create(c.Request.Context(), &req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, created)
+}
+
+func (h *APIKeyHandler) ListAPIKeys(c *gin.Context) {
+	keys, err := h.svc.List(c.Request.Context())
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, keys)
+}
+
+func (h *APIKeyHandler) GetAPIKey(c *gin.Context) {
+	id, ok := paramID(c)
+	if !ok {
+		return
+	}
+
+	key, err := h.svc.Get(c.Request.Context(), id)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, key)
+}
+
+// ยกเลิก api key
+func (h *APIKeyHandler) RevokeAPIKey(c *gin.Context) {
+	id, ok := paramID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.svc.Revoke(c.Request.Context(), id); err != nil {
+		c.Error(err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
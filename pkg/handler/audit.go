@@ -0,0 +1,37 @@
+package handler
+
+import (
+	"customer-api/pkg/repository"
+	"customer-api/pkg/service"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AuditHandler struct {
+	svc service.AuditService
+}
+
+func NewAuditHandler(svc service.AuditService) *AuditHandler {
+	return &AuditHandler{svc: svc}
+}
+
+// list audit log, newest first (optionally filter by entity_type, entity_id or actor)
+func (h *AuditHandler) ListAuditLogs(c *gin.Context) {
+	filter := repository.AuditFilter{
+		EntityType: c.Query("entity_type"),
+		Actor:      c.Query("actor"),
+	}
+	entityID, ok := queryID(c, "entity_id")
+	if !ok {
+		return
+	}
+	filter.EntityID = entityID
+
+	list, err := h.svc.List(c.Request.Context(), filter, pageParams(c))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, list)
+}
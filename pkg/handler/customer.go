@@ -1,8 +1,12 @@
 package handler
 
 import (
+	"customer-api/pkg/pagination"
+	"customer-api/pkg/problem"
 	"customer-api/pkg/repository"
 	"customer-api/pkg/service"
+	"customer-api/pkg/validation"
+	"mime"
 	"net/http"
 	"strconv"
 
@@ -11,6 +15,16 @@ import (
 	"github.com/google/uuid"
 )
 
+// maxImportBytes caps the size of an uploaded import file.
+const maxImportBytes = 32 << 20
+
+// importFormats maps upload media types to import formats.
+var importFormats = map[string]service.ImportFormat{
+	"text/csv":             service.ImportCSV,
+	"application/x-ndjson": service.ImportNDJSON,
+	"application/jsonl":    service.ImportNDJSON,
+}
+
 type CustomerHandler struct {
 	svc         service.CustomerService
 	validate    *validator.Validate
@@ -20,27 +34,20 @@ type CustomerHandler struct {
 func NewCustomerHandler(svc service.CustomerService, productRepo repository.ProductRepository) *CustomerHandler {
 	return &CustomerHandler{
 		svc:         svc,
-		validate:    validator.New(),
+		validate:    validation.New(),
 		productRepo: productRepo,
 	}
 }
 
 func (h *CustomerHandler) CreateCustomer(c *gin.Context) {
 	var req service.CreateCustomerRequest
-
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if !bindJSON(c, h.validate, &req) {
 		return
 	}
 
-	if err := h.validate.Struct(req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
-	}
-
-	created, err := h.svc.Create(&req)
+	created, err := h.svc.Create(c.Request.Context(), &req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 
@@ -48,28 +55,19 @@ func (h *CustomerHandler) CreateCustomer(c *gin.Context) {
 }
 
 func (h *CustomerHandler) UpdateByID(c *gin.Context) {
-	idParam := c.Param("id")
-	id, err := uuid.Parse(idParam)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+	id, ok := paramID(c)
+	if !ok {
 		return
 	}
 
 	var req service.UpdateCustomerRequest
-
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
-	}
-
-	if err := h.validate.Struct(req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if !bindJSON(c, h.validate, &req) {
 		return
 	}
 
-	cust, err := h.svc.Update(id, &req)
+	cust, err := h.svc.Update(c.Request.Context(), id, &req)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 	c.JSON(http.StatusOK, cust)
@@ -77,24 +75,22 @@ func (h *CustomerHandler) UpdateByID(c *gin.Context) {
 
 func (h *CustomerHandler) Get(c *gin.Context) {
 	keyword := c.Query("keyword")
-	limit, _ := strconv.Atoi(c.Query("limit"))
-	offset, _ := strconv.Atoi(c.Query("offset"))
 
-	cuts, err := h.svc.List(keyword, limit, offset)
+	cuts, err := h.svc.List(c.Request.Context(), keyword, pageParams(c))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
-	var responses []service.CustomerResponse
+	responses := make([]service.CustomerResponse, 0, len(cuts.Items))
 
-	for _, v := range cuts {
+	for _, v := range cuts.Items {
 		var feedbackResponse []service.FeedbackResponse
 		uniqueProduct := make(map[uuid.UUID][]service.CommentResponse)
 		productName := make(map[uuid.UUID]string)
 		for _, f := range v.Feedbacks {
-			p, productErr := h.productRepo.GetByID(f.ProductID)
+			p, productErr := h.productRepo.GetByID(c.Request.Context(), f.ProductID)
 			if productErr != nil {
-				c.JSON(http.StatusInternalServerError, gin.H{"error": productErr.Error()})
+				c.Error(productErr)
 				return
 			}
 			productName[p.ID] = p.Name
@@ -121,38 +117,81 @@ func (h *CustomerHandler) Get(c *gin.Context) {
 		responses = append(responses, customerResponse)
 	}
 
-	c.JSON(http.StatusOK, responses)
+	c.JSON(http.StatusOK, pagination.Page[service.CustomerResponse]{
+		Items:      responses,
+		NextCursor: cuts.NextCursor,
+		Total:      cuts.Total,
+	})
 }
 
 func (h *CustomerHandler) GetByID(c *gin.Context) {
-	idParam := c.Param("id")
-	id, err := uuid.Parse(idParam)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+	id, ok := paramID(c)
+	if !ok {
 		return
 	}
-	cust, err := h.svc.Get(id)
+	cust, err := h.svc.Get(c.Request.Context(), id)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 	c.JSON(http.StatusOK, cust)
 }
 
 func (h *CustomerHandler) DeleteByID(c *gin.Context) {
-	idParam := c.Param("id")
-	id, err := uuid.Parse(idParam)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+	id, ok := paramID(c)
+	if !ok {
 		return
 	}
 
-	err = h.svc.Delete(id)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := h.svc.Delete(c.Request.Context(), id); err != nil {
+		c.Error(err)
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "delete successfully"})
 
 }
+
+// ImportCustomers upserts customers from a CSV or NDJSON body. The format
+// comes from ?format= or the Content-Type; ?dry_run=true only reports what
+// would change. Rows that fail are listed in the report, not as an error.
+// When the import stops partway, say because the body outgrew
+// maxImportBytes, the problem carries the report of what was committed.
+func (h *CustomerHandler) ImportCustomers(c *gin.Context) {
+	format := service.ImportFormat(c.Query("format"))
+	if format == "" {
+		mt, _, _ := mime.ParseMediaType(c.GetHeader("Content-Type"))
+		format = importFormats[mt]
+	}
+	if format == "" {
+		c.Error(badRequest("invalid_query", "format must be csv or ndjson, or sent as the Content-Type"))
+		return
+	}
+
+	dryRun := false
+	if v := c.Query("dry_run"); v != "" {
+		var err error
+		if dryRun, err = strconv.ParseBool(v); err != nil {
+			c.Error(badRequest("invalid_query", "dry_run must be true or false"))
+			return
+		}
+	}
+
+	if c.Request.ContentLength > maxImportBytes {
+		c.Error(&http.MaxBytesError{Limit: maxImportBytes})
+		return
+	}
+	body := http.MaxBytesReader(c.Writer, c.Request.Body, maxImportBytes)
+	report, err := h.svc.Import(c.Request.Context(), body, service.ImportOptions{Format: format, DryRun: dryRun})
+	if err != nil && report != nil {
+		// batches before the failure are committed, so the client needs
+		// the report to know which rows were written
+		problem.Abort(c, problemFor(c, err).With("report", report))
+		return
+	}
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
@@ -0,0 +1,75 @@
+package handler
+
+import (
+	"context"
+	"customer-api/pkg/service"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// fakeImporter commits a batch on its first read, as the real import does
+// once a batch is full, then reads the rest of the body.
+type fakeImporter struct {
+	service.CustomerService
+	called bool
+}
+
+func (f *fakeImporter) Import(_ context.Context, r io.Reader, _ service.ImportOptions) (*service.ImportReport, error) {
+	f.called = true
+	report := &service.ImportReport{Rows: 1, Created: 1, Errors: []service.RowError{}}
+	_, err := io.Copy(io.Discard, r)
+	return report, err
+}
+
+func TestImportCustomersBodyTooLarge(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tests := []struct {
+		name       string
+		length     int64
+		wantCalled bool
+		wantReport bool
+	}{
+		{"declared length over the limit", maxImportBytes + 1, false, false},
+		{"undeclared length outgrows the limit", -1, true, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := &fakeImporter{}
+			r := gin.New()
+			r.Use(Errors())
+			r.POST("/customers/import", NewCustomerHandler(svc, nil).ImportCustomers)
+
+			req := httptest.NewRequest(http.MethodPost, "/customers/import?format=csv",
+				strings.NewReader(strings.Repeat("a", maxImportBytes+1)))
+			req.ContentLength = tt.length
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if w.Code != http.StatusRequestEntityTooLarge {
+				t.Fatalf("status = %d, want 413", w.Code)
+			}
+			if svc.called != tt.wantCalled {
+				t.Errorf("Import called = %v, want %v", svc.called, tt.wantCalled)
+			}
+			var body struct {
+				Code   string                `json:"code"`
+				Report *service.ImportReport `json:"report"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+				t.Fatal(err)
+			}
+			if body.Code != "body_too_large" {
+				t.Errorf("code = %q, want body_too_large", body.Code)
+			}
+			if got := body.Report != nil && body.Report.Created == 1; got != tt.wantReport {
+				t.Errorf("report = %+v, want it included: %v", body.Report, tt.wantReport)
+			}
+		})
+	}
+}
@@ -0,0 +1,159 @@
+package handler
+
+import (
+	"customer-api/pkg/logging"
+	"customer-api/pkg/pagination"
+	"customer-api/pkg/problem"
+	"customer-api/pkg/service"
+	"customer-api/pkg/validation"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+)
+
+// requestError rejects input a handler cannot even pass to a service, such
+// as an id that is not a UUID or a body that is not JSON.
+type requestError struct {
+	code   string
+	detail string
+}
+
+func (e *requestError) Error() string {
+	return e.detail
+}
+
+func badRequest(code, detail string) error {
+	return &requestError{code: code, detail: detail}
+}
+
+var (
+	errInvalidID   = badRequest("invalid_id", "id must be a UUID")
+	errEmptyBody   = badRequest("malformed_body", "request body is empty")
+	errInvalidBody = badRequest("malformed_body", "request body is not valid JSON")
+)
+
+// Errors renders the last error a handler attached with c.Error as an
+// application/problem+json response. Errors outside the domain are logged
+// and answered with a plain 500 so database messages never reach clients.
+func Errors() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+		if len(c.Errors) == 0 || c.Writer.Written() {
+			return
+		}
+		problem.Abort(c, problemFor(c, c.Errors.Last().Err))
+	}
+}
+
+// NotFound answers requests that match no route.
+func NotFound(c *gin.Context) {
+	problem.Abort(c, problem.New(http.StatusNotFound, "route_not_found", "no route matches "+c.Request.Method+" "+c.Request.URL.Path))
+}
+
+func problemFor(c *gin.Context, err error) *problem.Problem {
+	var (
+		rerr *requestError
+		verr *service.ValidationError
+		derr *service.Error
+		merr *http.MaxBytesError
+	)
+	switch {
+	case errors.As(err, &rerr):
+		return problem.New(http.StatusBadRequest, rerr.code, rerr.detail)
+	case errors.As(err, &verr):
+		p := problem.New(http.StatusUnprocessableEntity, "validation_failed", "the request has invalid fields")
+		p.Fields = verr.Fields
+		return p
+	case errors.As(err, &derr):
+		p := problem.New(kindStatus(derr.Kind), derr.Code, derr.Message)
+		p.Fields = derr.Fields
+		return p
+	case errors.As(err, &merr):
+		return problem.New(http.StatusRequestEntityTooLarge, "body_too_large",
+			"request body exceeds "+strconv.FormatInt(merr.Limit, 10)+" bytes")
+	case errors.Is(err, pagination.ErrInvalidCursor):
+		return problem.New(http.StatusBadRequest, "invalid_cursor", "cursor is malformed or from another list")
+	}
+
+	ctx := c.Request.Context()
+	logging.FromContext(ctx).ErrorContext(ctx, "request failed", "error", err)
+	return problem.New(http.StatusInternalServerError, "internal", "internal server error")
+}
+
+func kindStatus(kind error) int {
+	switch kind {
+	case service.ErrNotFound:
+		return http.StatusNotFound
+	case service.ErrConflict:
+		return http.StatusConflict
+	case service.ErrValidation:
+		return http.StatusUnprocessableEntity
+	case service.ErrForbidden:
+		return http.StatusForbidden
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// bindJSON decodes the body into req and validates it. On failure it
+// attaches the error for Errors to render and returns false.
+func bindJSON(c *gin.Context, v *validator.Validate, req any) bool {
+	if err := c.ShouldBindJSON(req); err != nil {
+		c.Error(bindError(err))
+		return false
+	}
+	if err := v.Struct(req); err != nil {
+		if fields := validation.Fields(err); fields != nil {
+			err = &service.ValidationError{Fields: fields}
+		}
+		c.Error(err)
+		return false
+	}
+	return true
+}
+
+// bindError describes a body that could not be decoded without echoing
+// decoder internals.
+func bindError(err error) error {
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.Is(err, io.EOF):
+		return errEmptyBody
+	case errors.As(err, &typeErr) && typeErr.Field != "":
+		return &service.ValidationError{Fields: map[string]string{
+			typeErr.Field: "has the wrong type",
+		}}
+	default:
+		return errInvalidBody
+	}
+}
+
+// paramID parses the :id path parameter, attaching errInvalidID on failure.
+func paramID(c *gin.Context) (uuid.UUID, bool) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.Error(errInvalidID)
+		return uuid.Nil, false
+	}
+	return id, true
+}
+
+// queryID parses an optional UUID query parameter; nil means absent.
+func queryID(c *gin.Context, name string) (*uuid.UUID, bool) {
+	v := c.Query(name)
+	if v == "" {
+		return nil, true
+	}
+	id, err := uuid.Parse(v)
+	if err != nil {
+		c.Error(badRequest("invalid_query", name+" must be a UUID"))
+		return nil, false
+	}
+	return &id, true
+}
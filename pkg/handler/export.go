@@ -0,0 +1,151 @@
+package handler
+
+import (
+	"customer-api/pkg/export"
+	"customer-api/pkg/logging"
+	"customer-api/pkg/model"
+	"customer-api/pkg/repository"
+	"customer-api/pkg/service"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ExportHandler struct {
+	customers    service.CustomerService
+	feedbacks    service.FeedbackService
+	interactions service.InteractionService
+	// timeout replaces the server's write timeout for one download.
+	timeout time.Duration
+}
+
+func NewExportHandler(customers service.CustomerService, feedbacks service.FeedbackService, interactions service.InteractionService, timeout time.Duration) *ExportHandler {
+	return &ExportHandler{
+		customers:    customers,
+		feedbacks:    feedbacks,
+		interactions: interactions,
+		timeout:      timeout,
+	}
+}
+
+var customerColumns = []export.Column[model.Customer]{
+	{Name: "id", Value: func(c *model.Customer) any { return c.ID.String() }},
+	{Name: "name", Value: func(c *model.Customer) any { return c.Name }},
+	{Name: "email", Value: func(c *model.Customer) any { return c.Email }},
+	{Name: "phone", Value: func(c *model.Customer) any { return c.Phone }},
+	{Name: "createdAt", Value: func(c *model.Customer) any { return c.CreatedAt }},
+	{Name: "updatedAt", Value: func(c *model.Customer) any { return c.UpdatedAt }},
+}
+
+var feedbackColumns = []export.Column[model.Feedback]{
+	{Name: "id", Value: func(f *model.Feedback) any { return f.ID.String() }},
+	{Name: "customerId", Value: func(f *model.Feedback) any { return f.CustomerID.String() }},
+	{Name: "productId", Value: func(f *model.Feedback) any { return f.ProductID.String() }},
+	{Name: "rating", Value: func(f *model.Feedback) any { return f.Rating }},
+	{Name: "comment", Value: func(f *model.Feedback) any { return f.Comment }},
+	{Name: "createdAt", Value: func(f *model.Feedback) any { return f.CreatedAt }},
+	{Name: "updatedAt", Value: func(f *model.Feedback) any { return f.UpdatedAt }},
+}
+
+var interactionColumns = []export.Column[model.Interaction]{
+	{Name: "id", Value: func(in *model.Interaction) any { return in.ID.String() }},
+	{Name: "customerId", Value: func(in *model.Interaction) any { return in.CustomerID.String() }},
+	{Name: "channel", Value: func(in *model.Interaction) any { return in.Channel }},
+	{Name: "description", Value: func(in *model.Interaction) any { return in.Description }},
+	{Name: "createdAt", Value: func(in *model.Interaction) any { return in.CreatedAt }},
+	{Name: "updatedAt", Value: func(in *model.Interaction) any { return in.UpdatedAt }},
+}
+
+// ExportCustomers streams the customers matching ?keyword=, as in GET /customers.
+func (h *ExportHandler) ExportCustomers(c *gin.Context) {
+	keyword := c.Query("keyword")
+	streamExport(c, h.timeout, "customers", customerColumns, func(fn func(*model.Customer) error) error {
+		return h.customers.Stream(c.Request.Context(), keyword, fn)
+	})
+}
+
+// ExportFeedbacks streams feedback filtered as in GET /feedbacks.
+func (h *ExportHandler) ExportFeedbacks(c *gin.Context) {
+	var filter repository.FeedbackFilter
+	var ok bool
+	if filter.CustomerID, ok = queryID(c, "customer_id"); !ok {
+		return
+	}
+	if filter.ProductID, ok = queryID(c, "product_id"); !ok {
+		return
+	}
+
+	streamExport(c, h.timeout, "feedbacks", feedbackColumns, func(fn func(*model.Feedback) error) error {
+		return h.feedbacks.Stream(c.Request.Context(), filter, fn)
+	})
+}
+
+// ExportInteractions streams interactions filtered as in GET /interactions.
+func (h *ExportHandler) ExportInteractions(c *gin.Context) {
+	filter, err := interactionFilterFromQuery(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	customerID, ok := queryID(c, "customer_id")
+	if !ok {
+		return
+	}
+	filter.CustomerID = customerID
+
+	streamExport(c, h.timeout, "interactions", interactionColumns, func(fn func(*model.Interaction) error) error {
+		return h.interactions.Stream(c.Request.Context(), filter, fn)
+	})
+}
+
+// streamExport writes the rows run produces as a download in the format
+// given by ?format=. An error before the first bytes go out is rendered as
+// a problem; after that the connection is dropped, so the client sees a
+// broken transfer rather than a file that looks complete.
+func streamExport[T any](c *gin.Context, timeout time.Duration, name string, cols []export.Column[T], run func(func(*T) error) error) {
+	format, ok := export.ParseFormat(strings.ToLower(c.Query("format")))
+	if !ok {
+		c.Error(badRequest("invalid_query", "format must be one of: "+strings.Join(export.Formats, ", ")))
+		return
+	}
+
+	// not every ResponseWriter supports deadlines; the server timeout then stays
+	_ = http.NewResponseController(c.Writer).SetWriteDeadline(time.Now().Add(timeout))
+
+	w, err := export.NewWriter(c.Writer, format, cols)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.Header("Content-Type", format.ContentType())
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s-%s.%s"`,
+		name, time.Now().UTC().Format("20060102T150405Z"), format))
+	c.Status(http.StatusOK)
+
+	err = run(w.Write)
+	if err == nil {
+		err = w.Close()
+	} else {
+		w.Abort()
+	}
+	if err == nil {
+		return
+	}
+	if !c.Writer.Written() {
+		c.Writer.Header().Del("Content-Type")
+		c.Writer.Header().Del("Content-Disposition")
+		if errors.Is(err, export.ErrTooManyRows) {
+			err = badRequest("too_many_rows", err.Error()+"; narrow the filters or use csv or ndjson")
+		}
+		c.Error(err)
+		return
+	}
+
+	ctx := c.Request.Context()
+	logging.FromContext(ctx).ErrorContext(ctx, "export aborted", "export", name, "error", err)
+	panic(http.ErrAbortHandler)
+}
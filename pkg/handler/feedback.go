@@ -2,27 +2,32 @@ package handler
 
 import (
 	"customer-api/pkg/model"
+	"customer-api/pkg/repository"
+	"customer-api/pkg/service"
+	"customer-api/pkg/validation"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
 	"github.com/google/uuid"
-	"gorm.io/gorm"
 )
 
 type FeedbackHandler struct {
-	db *gorm.DB
+	svc      service.FeedbackService
+	validate *validator.Validate
 }
 
-func NewFeedbackHandler(db *gorm.DB) *FeedbackHandler {
+func NewFeedbackHandler(svc service.FeedbackService) *FeedbackHandler {
 	return &FeedbackHandler{
-		db: db,
+		svc:      svc,
+		validate: validation.New(),
 	}
 }
 
 type FeedbackRequest struct {
-	CustomerID string `json:"customerId"`
-	ProductID  string `json:"productId"`
-	Rating     int    `json:"rating"`
+	CustomerID string `json:"customerId" validate:"required,uuid"`
+	ProductID  string `json:"productId" validate:"required,uuid"`
+	Rating     int    `json:"rating" validate:"required,min=1,max=5"`
 	Comment    string `json:"comment"`
 }
 
@@ -35,45 +40,22 @@ type FeedbackResponse struct {
 
 // สร้าง feedback ใหม่
 func (h *FeedbackHandler) CreateFeedback(c *gin.Context) {
-	var req FeedbackRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
-	}
-
-	customerId, _ := uuid.Parse(req.CustomerID)
-	productId, _ := uuid.Parse(req.ProductID)
-
-	feedback := model.Feedback{
-		ID:         uuid.New(),
-		CustomerID: customerId,
-		ProductID:  productId,
-		Rating:     req.Rating,
-		Comment:    req.Comment,
-	}
-
-	if err := h.db.Create(&feedback).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	in, ok := h.bind(c)
+	if !ok {
 		return
 	}
 
-	var customer model.Customer
-	if err := h.db.First(&customer, customerId).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
-	}
-
-	var product model.Product
-	if err := h.db.First(&product, productId).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	detail, err := h.svc.Create(c.Request.Context(), in)
+	if err != nil {
+		c.Error(err)
 		return
 	}
 
 	response := FeedbackResponse{
-		Customer:  customer,
-		ProductID: product,
-		Rating:    feedback.Rating,
-		Comment:   feedback.Comment,
+		Customer:  *detail.Customer,
+		ProductID: *detail.Product,
+		Rating:    detail.Feedback.Rating,
+		Comment:   detail.Feedback.Comment,
 	}
 
 	c.JSON(http.StatusCreated, response)
@@ -81,20 +63,14 @@ func (h *FeedbackHandler) CreateFeedback(c *gin.Context) {
 
 // อ่าน feedback ด้วย id
 func (h *FeedbackHandler) GetFeedback(c *gin.Context) {
-	idParam := c.Param("id")
-	id, err := uuid.Parse(idParam)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid UUID"})
+	id, ok := paramID(c)
+	if !ok {
 		return
 	}
 
-	var feedback model.Feedback
-	if err := h.db.First(&feedback, "id = ?", id).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			c.JSON(http.StatusNotFound, gin.H{"error": "feedback not found"})
-			return
-		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	feedback, err := h.svc.Get(c.Request.Context(), id)
+	if err != nil {
+		c.Error(err)
 		return
 	}
 
@@ -103,39 +79,19 @@ func (h *FeedbackHandler) GetFeedback(c *gin.Context) {
 
 // อัพเดต feedback
 func (h *FeedbackHandler) UpdateFeedback(c *gin.Context) {
-	idParam := c.Param("id")
-	id, err := uuid.Parse(idParam)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid UUID"})
-		return
-	}
-
-	var req FeedbackRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	id, ok := paramID(c)
+	if !ok {
 		return
 	}
 
-	var feedback model.Feedback
-	if err := h.db.First(&feedback, "id = ?", id).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			c.JSON(http.StatusNotFound, gin.H{"error": "feedback not found"})
-			return
-		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	in, ok := h.bind(c)
+	if !ok {
 		return
 	}
 
-	customerId, _ := uuid.Parse(req.CustomerID)
-	productId, _ := uuid.Parse(req.ProductID)
-
-	feedback.CustomerID = customerId
-	feedback.ProductID = productId
-	feedback.Rating = req.Rating
-	feedback.Comment = req.Comment
-
-	if err := h.db.Save(&feedback).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	feedback, err := h.svc.Update(c.Request.Context(), id, in)
+	if err != nil {
+		c.Error(err)
 		return
 	}
 
@@ -144,15 +100,13 @@ func (h *FeedbackHandler) UpdateFeedback(c *gin.Context) {
 
 // ลบ feedback
 func (h *FeedbackHandler) DeleteFeedback(c *gin.Context) {
-	idParam := c.Param("id")
-	id, err := uuid.Parse(idParam)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid UUID"})
+	id, ok := paramID(c)
+	if !ok {
 		return
 	}
 
-	if err := h.db.Delete(&model.Feedback{}, "id = ?", id).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := h.svc.Delete(c.Request.Context(), id); err != nil {
+		c.Error(err)
 		return
 	}
 
@@ -161,36 +115,37 @@ func (h *FeedbackHandler) DeleteFeedback(c *gin.Context) {
 
 // list feedback ทั้งหมด (optionally filter by customer or product)
 func (h *FeedbackHandler) ListFeedbacks(c *gin.Context) {
-	var feedbacks []model.Feedback
+	var filter repository.FeedbackFilter
 
-	customerID := c.Query("customer_id")
-	productID := c.Query("product_id")
-
-	query := h.db.Model(&model.Feedback{})
-
-	if customerID != "" {
-		cid, err := uuid.Parse(customerID)
-		if err == nil {
-			query = query.Where("customer_id = ?", cid)
-		}
+	var ok bool
+	if filter.CustomerID, ok = queryID(c, "customer_id"); !ok {
+		return
 	}
-
-	if productID != "" {
-		pid, err := uuid.Parse(productID)
-		if err == nil {
-			query = query.Where("product_id = ?", pid)
-		}
+	if filter.ProductID, ok = queryID(c, "product_id"); !ok {
+		return
 	}
 
-	if err := query.Find(&feedbacks).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	feedbacks, err := h.svc.List(c.Request.Context(), filter, pageParams(c))
+	if err != nil {
+		c.Error(err)
 		return
 	}
 
-	// var res []FeedbackResponse
-	// for _, f := range feedbacks {
-	// 	res = append(res, f)
-	// }
-
 	c.JSON(http.StatusOK, feedbacks)
 }
+
+// bind reads and validates a FeedbackRequest into a service input.
+func (h *FeedbackHandler) bind(c *gin.Context) (*service.FeedbackInput, bool) {
+	var req FeedbackRequest
+	if !bindJSON(c, h.validate, &req) {
+		return nil, false
+	}
+
+	// both ids were checked by the uuid validation above
+	return &service.FeedbackInput{
+		CustomerID: uuid.MustParse(req.CustomerID),
+		ProductID:  uuid.MustParse(req.ProductID),
+		Rating:     req.Rating,
+		Comment:    req.Comment,
+	}, true
+}
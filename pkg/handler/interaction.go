@@ -0,0 +1,171 @@
+package handler
+
+import (
+	"customer-api/pkg/repository"
+	"customer-api/pkg/service"
+	"customer-api/pkg/validation"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+)
+
+type InteractionHandler struct {
+	svc      service.InteractionService
+	validate *validator.Validate
+}
+
+func NewInteractionHandler(svc service.InteractionService) *InteractionHandler {
+	return &InteractionHandler{
+		svc:      svc,
+		validate: validation.New(),
+	}
+}
+
+// บันทึก interaction ของลูกค้า
+func (h *InteractionHandler) CreateInteraction(c *gin.Context) {
+	customerID, ok := paramID(c)
+	if !ok {
+		return
+	}
+
+	var req service.CreateInteractionRequest
+	if !bindJSON(c, h.validate, &req) {
+		return
+	}
+
+	created, err := h.svc.Create(c.Request.Context(), customerID, &req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, created)
+}
+
+// list interaction ของลูกค้าคนเดียว
+func (h *InteractionHandler) ListCustomerInteractions(c *gin.Context) {
+	customerID, ok := paramID(c)
+	if !ok {
+		return
+	}
+
+	filter, err := interactionFilterFromQuery(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	filter.CustomerID = &customerID
+
+	h.list(c, filter)
+}
+
+// list interaction ทั้งหมด (optionally filter by customer, channel or date range)
+func (h *InteractionHandler) ListInteractions(c *gin.Context) {
+	filter, err := interactionFilterFromQuery(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	customerID, ok := queryID(c, "customer_id")
+	if !ok {
+		return
+	}
+	filter.CustomerID = customerID
+
+	h.list(c, filter)
+}
+
+func (h *InteractionHandler) GetInteraction(c *gin.Context) {
+	id, ok := paramID(c)
+	if !ok {
+		return
+	}
+
+	in, err := h.svc.Get(c.Request.Context(), id)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, in)
+}
+
+func (h *InteractionHandler) UpdateInteraction(c *gin.Context) {
+	id, ok := paramID(c)
+	if !ok {
+		return
+	}
+
+	var req service.UpdateInteractionRequest
+	if !bindJSON(c, h.validate, &req) {
+		return
+	}
+
+	in, err := h.svc.Update(c.Request.Context(), id, &req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, in)
+}
+
+func (h *InteractionHandler) DeleteInteraction(c *gin.Context) {
+	id, ok := paramID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.svc.Delete(c.Request.Context(), id); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (h *InteractionHandler) list(c *gin.Context, filter repository.InteractionFilter) {
+	list, err := h.svc.List(c.Request.Context(), filter, pageParams(c))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, list)
+}
+
+func interactionFilterFromQuery(c *gin.Context) (repository.InteractionFilter, error) {
+	var filter repository.InteractionFilter
+
+	filter.Channel = strings.ToLower(c.Query("channel"))
+
+	if v := c.Query("from"); v != "" {
+		from, _, err := parseTimeParam(v)
+		if err != nil {
+			return filter, badRequest("invalid_query", "from must be RFC3339 or YYYY-MM-DD")
+		}
+		filter.From = &from
+	}
+	if v := c.Query("to"); v != "" {
+		to, dateOnly, err := parseTimeParam(v)
+		if err != nil {
+			return filter, badRequest("invalid_query", "to must be RFC3339 or YYYY-MM-DD")
+		}
+		// a bare date includes the whole day
+		if dateOnly {
+			to = to.AddDate(0, 0, 1)
+		}
+		filter.To = &to
+	}
+	return filter, nil
+}
+
+// parseTimeParam accepts either an RFC3339 timestamp or a YYYY-MM-DD date.
+func parseTimeParam(v string) (time.Time, bool, error) {
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return t, false, nil
+	}
+	t, err := time.Parse(time.DateOnly, v)
+	return t, true, err
+}
@@ -0,0 +1,17 @@
+package handler
+
+import (
+	"customer-api/pkg/pagination"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// pageParams reads the limit and cursor query parameters of a list request.
+func pageParams(c *gin.Context) pagination.Params {
+	limit, _ := strconv.Atoi(c.Query("limit"))
+	return pagination.Params{
+		Limit:  limit,
+		Cursor: c.Query("cursor"),
+	}
+}
@@ -0,0 +1,50 @@
+package handler
+
+import (
+	"customer-api/pkg/service"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type PrivacyHandler struct {
+	svc service.PrivacyService
+}
+
+func NewPrivacyHandler(svc service.PrivacyService) *PrivacyHandler {
+	return &PrivacyHandler{svc: svc}
+}
+
+// ExportPersonalData answers a subject access request with everything
+// stored about the customer, as a JSON download.
+func (h *PrivacyHandler) ExportPersonalData(c *gin.Context) {
+	id, ok := paramID(c)
+	if !ok {
+		return
+	}
+
+	data, err := h.svc.Export(c.Request.Context(), id)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="personal-data-%s.json"`, id))
+	c.JSON(http.StatusOK, data)
+}
+
+// ErasePersonalData anonymises the customer for good. There is no undo.
+func (h *PrivacyHandler) ErasePersonalData(c *gin.Context) {
+	id, ok := paramID(c)
+	if !ok {
+		return
+	}
+
+	result, err := h.svc.Erase(c.Request.Context(), id)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
@@ -0,0 +1,100 @@
+package handler
+
+import (
+	"customer-api/pkg/service"
+	"customer-api/pkg/validation"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+)
+
+type ProductHandler struct {
+	svc      service.ProductService
+	validate *validator.Validate
+}
+
+func NewProductHandler(svc service.ProductService) *ProductHandler {
+	return &ProductHandler{
+		svc:      svc,
+		validate: validation.New(),
+	}
+}
+
+func (h *ProductHandler) CreateProduct(c *gin.Context) {
+	var req service.CreateProductRequest
+
+	if !bindJSON(c, h.validate, &req) {
+		return
+	}
+
+	created, err := h.svc.Create(c.Request.Context(), &req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, created)
+}
+
+// list products (optionally filter by category and search by name)
+func (h *ProductHandler) ListProducts(c *gin.Context) {
+	keyword := c.Query("keyword")
+	category := c.Query("category")
+
+	products, err := h.svc.List(c.Request.Context(), keyword, category, pageParams(c))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, products)
+}
+
+func (h *ProductHandler) GetProduct(c *gin.Context) {
+	id, ok := paramID(c)
+	if !ok {
+		return
+	}
+
+	product, err := h.svc.Get(c.Request.Context(), id)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, product)
+}
+
+func (h *ProductHandler) UpdateProduct(c *gin.Context) {
+	id, ok := paramID(c)
+	if !ok {
+		return
+	}
+
+	var req service.UpdateProductRequest
+
+	if !bindJSON(c, h.validate, &req) {
+		return
+	}
+
+	product, err := h.svc.Update(c.Request.Context(), id, &req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, product)
+}
+
+func (h *ProductHandler) DeleteProduct(c *gin.Context) {
+	id, ok := paramID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.svc.Delete(c.Request.Context(), id); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
@@ -1,16 +1,21 @@
 package handler
 
 import (
-	"context"
-	"log"
+	"customer-api/pkg/metrics"
+	"customer-api/pkg/tracing"
+	"customer-api/pkg/validation"
+	"fmt"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
 	"github.com/segmentio/kafka-go"
 )
 
 type KafkaHandler struct {
-	writer *kafka.Writer
+	writer   *kafka.Writer
+	topic    string
+	validate *validator.Validate
 }
 
 func NewKafkaHandler(brokers []string, topic string) *KafkaHandler {
@@ -19,6 +24,8 @@ func NewKafkaHandler(brokers []string, topic string) *KafkaHandler {
 			Brokers: brokers,
 			Topic:   topic,
 		}),
+		topic:    topic,
+		validate: validation.New(),
 	}
 }
 
@@ -26,26 +33,29 @@ func (h *KafkaHandler) Close() error {
 	return h.writer.Close()
 }
 
-func (h *KafkaHandler) Publish(c *gin.Context) {
-	var json struct {
-		Key   string `json:"key"`
-		Value string `json:"value" binding:"required"`
-	}
+// PublishRequest is a raw message for POST /publish.
+type PublishRequest struct {
+	Key   string `json:"key"`
+	Value string `json:"value" validate:"required"`
+}
 
-	if err := c.ShouldBindJSON(&json); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+func (h *KafkaHandler) Publish(c *gin.Context) {
+	var req PublishRequest
+	if !bindJSON(c, h.validate, &req) {
 		return
 	}
 
 	msg := kafka.Message{
-		Key:   []byte(json.Key),
-		Value: []byte(json.Value),
+		Key:   []byte(req.Key),
+		Value: []byte(req.Value),
 	}
 
-	err := h.writer.WriteMessages(context.Background(), msg)
+	ctx, span := tracing.StartPublish(c.Request.Context(), h.topic, &msg)
+	err := h.writer.WriteMessages(ctx, msg)
+	tracing.Finish(span, err)
+	metrics.Published(h.topic, 1, err)
 	if err != nil {
-		log.Printf("failed to write message: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to publish message"})
+		c.Error(fmt.Errorf("publish to %s: %w", h.topic, err))
 		return
 	}
 
@@ -0,0 +1,149 @@
+// Package health serves liveness and readiness probes built from
+// pluggable checks.
+package health
+
+import (
+	"context"
+	"customer-api/pkg/logging"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Check reports whether one dependency is usable. It must return once ctx
+// is done.
+type Check func(ctx context.Context) error
+
+var errShuttingDown = errors.New("shutting down")
+
+type namedCheck struct {
+	name  string
+	check Check
+}
+
+// Checker runs the readiness checks. Liveness only says the process is
+// serving requests; it never depends on other systems, so an outage of
+// Postgres or Kafka does not get every replica restarted.
+type Checker struct {
+	timeout      time.Duration
+	mu           sync.RWMutex
+	checks       []namedCheck
+	shuttingDown atomic.Bool
+}
+
+// NewChecker returns a Checker that gives each check timeout to finish.
+func NewChecker(timeout time.Duration) *Checker {
+	return &Checker{timeout: timeout}
+}
+
+// Add registers a readiness check under name.
+func (h *Checker) Add(name string, check Check) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.checks = append(h.checks, namedCheck{name: name, check: check})
+}
+
+// Shutdown makes readiness fail from now on so load balancers stop
+// sending traffic while in-flight requests drain.
+func (h *Checker) Shutdown() {
+	h.shuttingDown.Store(true)
+}
+
+// Result is the outcome of one check. Error is a generic reason; the
+// detail is only logged.
+type Result struct {
+	Status     string  `json:"status"`
+	DurationMs float64 `json:"durationMs"`
+	Error      string  `json:"error,omitempty"`
+}
+
+// Report is the body of a probe response.
+type Report struct {
+	Status string            `json:"status"`
+	Checks map[string]Result `json:"checks,omitempty"`
+}
+
+const (
+	statusOK   = "ok"
+	statusFail = "fail"
+)
+
+// Run runs every check concurrently and reports whether all passed.
+func (h *Checker) Run(ctx context.Context) (Report, bool) {
+	h.mu.RLock()
+	checks := append([]namedCheck{}, h.checks...)
+	h.mu.RUnlock()
+
+	ctx, cancel := context.WithTimeout(ctx, h.timeout)
+	defer cancel()
+
+	results := make([]Result, len(checks))
+	errs := make([]error, len(checks))
+	var wg sync.WaitGroup
+	for i, c := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i], errs[i] = run(ctx, c.check)
+		}()
+	}
+	wg.Wait()
+
+	report := Report{Status: statusOK, Checks: make(map[string]Result, len(checks))}
+	for i, c := range checks {
+		report.Checks[c.name] = results[i]
+		if errs[i] != nil {
+			report.Status = statusFail
+			logging.FromContext(ctx).WarnContext(ctx, "readiness check failed", "check", c.name, "error", errs[i])
+		}
+	}
+	if h.shuttingDown.Load() {
+		report.Status = statusFail
+		report.Checks["shutdown"] = Result{Status: statusFail, Error: errShuttingDown.Error()}
+	}
+	return report, report.Status == statusOK
+}
+
+// run runs check and returns its result along with the error behind a
+// failure. The probes are public and errors can name hosts and brokers, so
+// the result only carries a generic reason and the error is logged.
+func run(ctx context.Context, check Check) (res Result, err error) {
+	start := time.Now()
+	defer func() {
+		if r := recover(); r != nil {
+			res, err = Result{Status: statusFail, Error: "check panicked"}, fmt.Errorf("check panicked: %v", r)
+		}
+		res.DurationMs = float64(time.Since(start).Microseconds()) / 1000
+	}()
+
+	if err := check(ctx); err != nil {
+		reason := "unavailable"
+		if errors.Is(err, context.DeadlineExceeded) {
+			reason = "timed out"
+		}
+		return Result{Status: statusFail, Error: reason}, err
+	}
+	return Result{Status: statusOK}, nil
+}
+
+// Live answers the liveness probe.
+func (h *Checker) Live(c *gin.Context) {
+	c.JSON(http.StatusOK, Report{Status: statusOK})
+}
+
+// Ready answers the readiness probe with the detail of every check, and
+// 503 if any failed or the service is shutting down.
+func (h *Checker) Ready(c *gin.Context) {
+	report, ok := h.Run(c.Request.Context())
+	code := http.StatusOK
+	if !ok {
+		code = http.StatusServiceUnavailable
+	}
+	c.Header("Cache-Control", "no-store")
+	c.JSON(code, report)
+}
@@ -0,0 +1,51 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestReadyHidesErrorDetail(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h := NewChecker(50 * time.Millisecond)
+	h.Add("postgres", func(context.Context) error { return nil })
+	h.Add("kafka", func(context.Context) error { return errors.New("dial tcp kafka-0.internal:9092: connection refused") })
+	h.Add("slow", func(ctx context.Context) error { <-ctx.Done(); return ctx.Err() })
+	h.Add("broken", func(context.Context) error { panic("boom") })
+
+	r := gin.New()
+	r.GET("/readyz", h.Ready)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+	if w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("status = %d, want 503", w.Code)
+	}
+	if body := w.Body.String(); strings.Contains(body, "kafka-0.internal") || strings.Contains(body, "boom") {
+		t.Errorf("body leaks error detail: %s", body)
+	}
+	var report Report
+	if err := json.Unmarshal(w.Body.Bytes(), &report); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]Result{
+		"postgres": {Status: statusOK},
+		"kafka":    {Status: statusFail, Error: "unavailable"},
+		"slow":     {Status: statusFail, Error: "timed out"},
+		"broken":   {Status: statusFail, Error: "check panicked"},
+	}
+	for name, w := range want {
+		got := report.Checks[name]
+		if got.Status != w.Status || got.Error != w.Error {
+			t.Errorf("%s = %+v, want status %q error %q", name, got, w.Status, w.Error)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package logging
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// slowQuery is the duration above which a statement is logged as a warning.
+const slowQuery = 200 * time.Millisecond
+
+// GormLogger writes GORM's logs with the logger of the statement's context,
+// so queries carry the request id of the request that issued them. Every
+// statement is logged at debug, slow ones at warn and failures at error.
+type GormLogger struct{}
+
+// LogMode implements logger.Interface. Levels come from the slog logger.
+func (l GormLogger) LogMode(logger.LogLevel) logger.Interface {
+	return l
+}
+
+// Info implements logger.Interface.
+func (GormLogger) Info(ctx context.Context, msg string, args ...any) {
+	FromContext(ctx).InfoContext(ctx, fmt.Sprintf(msg, args...))
+}
+
+// Warn implements logger.Interface.
+func (GormLogger) Warn(ctx context.Context, msg string, args ...any) {
+	FromContext(ctx).WarnContext(ctx, fmt.Sprintf(msg, args...))
+}
+
+// Error implements logger.Interface.
+func (GormLogger) Error(ctx context.Context, msg string, args ...any) {
+	FromContext(ctx).ErrorContext(ctx, fmt.Sprintf(msg, args...))
+}
+
+// ParamsFilter implements gorm.ParamsFilter. Bind parameters are dropped so
+// customer data stays out of the logs.
+func (GormLogger) ParamsFilter(ctx context.Context, sql string, params ...any) (string, []any) {
+	return sql, nil
+}
+
+// Trace implements logger.Interface.
+func (GormLogger) Trace(ctx context.Context, begin time.Time, fc func() (string, int64), err error) {
+	elapsed := time.Since(begin)
+	l := FromContext(ctx)
+
+	level := slog.LevelDebug
+	switch {
+	case err != nil && !errors.Is(err, gorm.ErrRecordNotFound):
+		level = slog.LevelError
+	case elapsed > slowQuery:
+		level = slog.LevelWarn
+	}
+	if !l.Enabled(ctx, level) {
+		return
+	}
+
+	sql, rows := fc()
+	attrs := []slog.Attr{
+		slog.String("sql", sql),
+		slog.Int64("rows", rows),
+		slog.Float64("duration_ms", float64(elapsed.Microseconds())/1000),
+	}
+	if err != nil {
+		attrs = append(attrs, slog.String("error", err.Error()))
+	}
+	l.LogAttrs(ctx, level, "query", attrs...)
+}
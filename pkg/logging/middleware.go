@@ -0,0 +1,76 @@
+package logging
+
+import (
+	"customer-api/pkg/problem"
+	"customer-api/pkg/requestid"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"runtime/debug"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Middleware puts a logger tagged with the request id, method and route in
+// the request context and writes one access log line per request. It must
+// run after requestid.Middleware.
+func Middleware(base *slog.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+
+		l := base.With(
+			slog.String("request_id", requestid.FromContext(c.Request.Context())),
+			slog.String("method", c.Request.Method),
+			slog.String("route", route),
+		)
+		c.Request = c.Request.WithContext(WithLogger(c.Request.Context(), l))
+
+		c.Next()
+
+		status := c.Writer.Status()
+		level := slog.LevelInfo
+		switch {
+		case status >= 500:
+			level = slog.LevelError
+		case status >= 400:
+			level = slog.LevelWarn
+		}
+		attrs := []slog.Attr{
+			slog.String("path", c.Request.URL.Path),
+			slog.Int("status", status),
+			slog.Float64("latency_ms", float64(time.Since(start).Microseconds())/1000),
+			slog.String("client_ip", c.ClientIP()),
+			slog.Int("bytes", max(c.Writer.Size(), 0)),
+		}
+		if len(c.Errors) > 0 {
+			attrs = append(attrs, slog.String("errors", c.Errors.String()))
+		}
+		// the request context now also holds the principal set by auth
+		l.LogAttrs(c.Request.Context(), level, "request", attrs...)
+	}
+}
+
+// Recovery turns a handler panic into a 500 and logs it with its stack.
+func Recovery() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		defer func() {
+			if r := recover(); r != nil {
+				// a handler dropping the connection on purpose
+				if r == http.ErrAbortHandler {
+					panic(r)
+				}
+				FromContext(c.Request.Context()).ErrorContext(c.Request.Context(), "panic",
+					slog.String("panic", fmt.Sprint(r)),
+					slog.String("stack", string(debug.Stack())),
+				)
+				problem.Abort(c, problem.New(http.StatusInternalServerError, "internal", "internal server error"))
+			}
+		}()
+		c.Next()
+	}
+}
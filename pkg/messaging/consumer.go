@@ -0,0 +1,259 @@
+package messaging
+
+import (
+	"context"
+	"customer-api/pkg/logging"
+	"customer-api/pkg/metrics"
+	"customer-api/pkg/tracing"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// Handler processes one message. Returning an error makes the consumer
+// retry the message and, once retries are exhausted, dead-letter it.
+type Handler func(ctx context.Context, msg kafka.Message) error
+
+type ConsumerConfig struct {
+	Brokers []string
+	GroupID string
+	// DeadLetterTopic receives messages whose handler kept failing. When
+	// empty such messages are logged and skipped.
+	DeadLetterTopic string
+	MaxRetries      int
+	RetryBackoff    time.Duration
+	// CommitTimeout bounds committing an offset, which also happens after
+	// Run's context is cancelled.
+	CommitTimeout time.Duration
+}
+
+// Consumer reads the topics that have a registered handler as one consumer
+// group and commits each message only after it was handled or dead-lettered.
+type Consumer struct {
+	cfg      ConsumerConfig
+	mu       sync.RWMutex
+	handlers map[string]Handler
+	dlq      *kafka.Writer
+	running  atomic.Bool
+}
+
+func NewConsumer(cfg ConsumerConfig) *Consumer {
+	if cfg.RetryBackoff <= 0 {
+		cfg.RetryBackoff = 500 * time.Millisecond
+	}
+	if cfg.CommitTimeout <= 0 {
+		cfg.CommitTimeout = 5 * time.Second
+	}
+	c := &Consumer{
+		cfg:      cfg,
+		handlers: make(map[string]Handler),
+	}
+	if cfg.DeadLetterTopic != "" {
+		c.dlq = &kafka.Writer{
+			Addr:                   kafka.TCP(cfg.Brokers...),
+			Topic:                  cfg.DeadLetterTopic,
+			RequiredAcks:           kafka.RequireAll,
+			BatchTimeout:           10 * time.Millisecond,
+			AllowAutoTopicCreation: true,
+		}
+	}
+	return c
+}
+
+// Handle registers h for topic. It must be called before Run.
+func (c *Consumer) Handle(topic string, h Handler) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.handlers[topic] = h
+}
+
+// Run consumes until ctx is cancelled. Read errors are logged and retried
+// instead of stopping the process.
+func (c *Consumer) Run(ctx context.Context) error {
+	topics := c.topics()
+	if len(topics) == 0 {
+		return errors.New("consumer: no handlers registered")
+	}
+
+	reader := kafka.NewReader(kafka.ReaderConfig{
+		Brokers:     c.cfg.Brokers,
+		GroupID:     c.cfg.GroupID,
+		GroupTopics: topics,
+	})
+	defer reader.Close()
+	c.running.Store(true)
+	defer c.running.Store(false)
+	if c.dlq != nil {
+		defer c.dlq.Close()
+	}
+
+	for {
+		m, err := reader.FetchMessage(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			logging.FromContext(ctx).ErrorContext(ctx, "consumer fetch failed", "error", err)
+			if !sleep(ctx, c.cfg.RetryBackoff) {
+				return nil
+			}
+			continue
+		}
+
+		if !c.process(ctx, m) {
+			// shutting down before the message was settled; it will be
+			// redelivered to the next member of the group
+			return nil
+		}
+
+		// commit even when shutdown began while m was handled, so it is
+		// not redelivered
+		if err := c.commit(ctx, reader, m); err != nil {
+			logging.FromContext(ctx).ErrorContext(ctx, "consumer commit failed", messageAttrs(m), "error", err)
+		}
+		if ctx.Err() != nil {
+			return nil
+		}
+	}
+}
+
+func (c *Consumer) commit(ctx context.Context, reader *kafka.Reader, m kafka.Message) error {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), c.cfg.CommitTimeout)
+	defer cancel()
+	return reader.CommitMessages(ctx, m)
+}
+
+// Running returns an error unless Run is consuming. It suits a readiness
+// check.
+func (c *Consumer) Running(context.Context) error {
+	if !c.running.Load() {
+		return errors.New("consumer is not running")
+	}
+	return nil
+}
+
+// process handles m with retries and dead-letters it on final failure. It
+// reports whether m is settled and may be committed.
+func (c *Consumer) process(ctx context.Context, m kafka.Message) bool {
+	c.mu.RLock()
+	h, ok := c.handlers[m.Topic]
+	c.mu.RUnlock()
+	if !ok {
+		logging.FromContext(ctx).WarnContext(ctx, "no handler for topic, skipping message", messageAttrs(m))
+		return true
+	}
+	metrics.Lag(m.Topic, strconv.Itoa(m.Partition), m.HighWaterMark-m.Offset-1)
+
+	ctx, span := tracing.StartProcess(ctx, m)
+	var err error
+	defer func() { tracing.Finish(span, err) }()
+
+	backoff := c.cfg.RetryBackoff
+	for attempt := 0; attempt <= c.cfg.MaxRetries; attempt++ {
+		if attempt > 0 {
+			if !sleep(ctx, backoff) {
+				return false
+			}
+			backoff *= 2
+		}
+		start := time.Now()
+		err = safeHandle(ctx, h, m)
+		metrics.HandleDuration(m.Topic, time.Since(start).Seconds())
+		if err == nil {
+			metrics.Consumed(m.Topic, metrics.ResultOK)
+			return true
+		}
+		metrics.Consumed(m.Topic, metrics.ResultError)
+		logging.FromContext(ctx).WarnContext(ctx, "handle message failed", messageAttrs(m), "attempt", attempt+1, "error", err)
+	}
+
+	return c.deadLetter(ctx, m, err)
+}
+
+func (c *Consumer) deadLetter(ctx context.Context, m kafka.Message, cause error) bool {
+	if c.dlq == nil {
+		logging.FromContext(ctx).ErrorContext(ctx, "dropping message", messageAttrs(m), "error", cause)
+		metrics.Consumed(m.Topic, metrics.ResultDropped)
+		return true
+	}
+
+	headers := append([]kafka.Header{}, m.Headers...)
+	headers = append(headers,
+		kafka.Header{Key: "dlq-original-topic", Value: []byte(m.Topic)},
+		kafka.Header{Key: "dlq-original-partition", Value: []byte(strconv.Itoa(m.Partition))},
+		kafka.Header{Key: "dlq-original-offset", Value: []byte(strconv.FormatInt(m.Offset, 10))},
+		kafka.Header{Key: "dlq-error", Value: []byte(cause.Error())},
+	)
+	msg := kafka.Message{Key: m.Key, Value: m.Value, Headers: headers}
+
+	// keep trying: committing without a dead letter would lose the message
+	for {
+		err := c.dlq.WriteMessages(ctx, msg)
+		metrics.Published(c.cfg.DeadLetterTopic, 1, err)
+		if err == nil {
+			metrics.Consumed(m.Topic, metrics.ResultDeadLettered)
+			return true
+		}
+		logging.FromContext(ctx).ErrorContext(ctx, "dead-letter message failed", messageAttrs(m), "error", err)
+		if !sleep(ctx, c.cfg.RetryBackoff) {
+			return false
+		}
+	}
+}
+
+func (c *Consumer) topics() []string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	topics := make([]string, 0, len(c.handlers))
+	for t := range c.handlers {
+		topics = append(topics, t)
+	}
+	return topics
+}
+
+// safeHandle turns a handler panic into an error so one bad message cannot
+// take the whole API down.
+func safeHandle(ctx context.Context, h Handler, m kafka.Message) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("handler panic: %v", r)
+		}
+	}()
+	return h(ctx, m)
+}
+
+// sleep waits for d and reports false if ctx was cancelled first.
+func sleep(ctx context.Context, d time.Duration) bool {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-t.C:
+		return true
+	}
+}
+
+// messageAttrs identifies m in log records.
+func messageAttrs(m kafka.Message) slog.Attr {
+	return slog.Group("message",
+		slog.String("topic", m.Topic),
+		slog.Int("partition", m.Partition),
+		slog.Int64("offset", m.Offset),
+	)
+}
+
+// LogHandler logs every message it receives.
+func LogHandler(ctx context.Context, m kafka.Message) error {
+	logging.FromContext(ctx).InfoContext(ctx, "message received", messageAttrs(m),
+		slog.String("key", string(m.Key)),
+		slog.String("value", string(m.Value)),
+	)
+	return nil
+}
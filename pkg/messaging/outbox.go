@@ -0,0 +1,156 @@
+package messaging
+
+import (
+	"context"
+	"customer-api/pkg/event"
+	"customer-api/pkg/logging"
+	"customer-api/pkg/metrics"
+	"customer-api/pkg/model"
+	"customer-api/pkg/repository"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// OutboxRelay publishes events recorded in the outbox table to Kafka in
+// insertion order. It claims a batch, publishes it with no transaction open
+// and then marks it sent. Delivery is at least once: a crash after
+// publishing but before marking rows sent re-sends them once the claim
+// lapses, so consumers dedupe on event id. A message that fails
+// MaxAttempts times is parked so the ones behind it can go out.
+type OutboxRelay struct {
+	repo repository.OutboxRepository
+	pub  event.Publisher
+
+	BatchSize      int
+	PollInterval   time.Duration
+	PublishTimeout time.Duration
+	// ClaimLease bounds how long a batch may take to publish and mark sent
+	// before another relay takes it over; keep it above PublishTimeout.
+	ClaimLease time.Duration
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+	// MaxAttempts is how often a message may fail to publish before it is
+	// parked.
+	MaxAttempts int
+}
+
+func NewOutboxRelay(repo repository.OutboxRepository, pub event.Publisher) *OutboxRelay {
+	return &OutboxRelay{
+		repo:           repo,
+		pub:            pub,
+		BatchSize:      100,
+		PollInterval:   time.Second,
+		PublishTimeout: 10 * time.Second,
+		ClaimLease:     time.Minute,
+		MinBackoff:     time.Second,
+		MaxBackoff:     time.Minute,
+		MaxAttempts:    10,
+	}
+}
+
+// Run relays pending events until ctx is cancelled. A failed batch is
+// retried with exponential backoff; later events wait behind it so
+// per-entity order is kept, until its failing message is parked. A batch that has started when ctx is cancelled
+// is still published and marked sent, so shutdown does not re-send it.
+func (r *OutboxRelay) Run(ctx context.Context) {
+	work := context.WithoutCancel(ctx)
+	var backoff time.Duration
+	for {
+		n, err := r.relay(work)
+
+		wait := r.PollInterval
+		switch {
+		case err != nil:
+			backoff = r.nextBackoff(backoff)
+			wait = backoff
+			logging.FromContext(ctx).ErrorContext(ctx, "outbox relay failed", "error", err, "retry_in", wait.String())
+		case n == r.BatchSize:
+			// more rows are likely waiting
+			backoff, wait = 0, 0
+		default:
+			backoff = 0
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(wait):
+		}
+	}
+}
+
+// relay publishes one claimed batch and returns how many messages went
+// out. When the batch fails its messages are sent one at a time to find
+// the one at fault; those before it are marked sent and those after it
+// wait for the next batch.
+func (r *OutboxRelay) relay(ctx context.Context) (int, error) {
+	msgs, err := r.repo.Claim(ctx, r.BatchSize, r.ClaimLease)
+	if err != nil || len(msgs) == 0 {
+		return 0, err
+	}
+
+	if err := r.publish(ctx, msgs); err != nil {
+		for i := range msgs {
+			if err := r.publish(ctx, msgs[i:i+1]); err != nil {
+				return i, r.fail(ctx, msgs[:i], msgs[i], msgs[i+1:], err)
+			}
+		}
+	}
+	return len(msgs), r.repo.MarkSent(ctx, messageIDs(msgs))
+}
+
+// fail settles a batch in which bad failed to publish with cause. bad is
+// parked once it has used up MaxAttempts, which is not an error for the
+// relay as the rest of the outbox can go on.
+func (r *OutboxRelay) fail(ctx context.Context, sent []model.OutboxMessage, bad model.OutboxMessage, rest []model.OutboxMessage, cause error) error {
+	err := r.repo.MarkSent(ctx, messageIDs(sent))
+	park := bad.Attempts+1 >= r.MaxAttempts
+	if park {
+		err = errors.Join(err, r.repo.Park(ctx, bad.ID, cause))
+	} else {
+		err = errors.Join(err, r.repo.Release(ctx, []int64{bad.ID}, cause))
+	}
+	err = errors.Join(err, r.repo.Unclaim(ctx, messageIDs(rest)))
+	if err != nil || !park {
+		return errors.Join(cause, err)
+	}
+
+	metrics.OutboxParked(bad.Topic)
+	logging.FromContext(ctx).ErrorContext(ctx, "outbox message parked",
+		"id", bad.ID, "event_id", bad.EventID, "type", bad.Type, "attempts", bad.Attempts+1, "error", cause)
+	return nil
+}
+
+func (r *OutboxRelay) publish(ctx context.Context, msgs []model.OutboxMessage) error {
+	events := make([]event.Event, 0, len(msgs))
+	for _, m := range msgs {
+		var e event.Event
+		if err := json.Unmarshal([]byte(m.Payload), &e); err != nil {
+			return err
+		}
+		events = append(events, e)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, r.PublishTimeout)
+	defer cancel()
+	return r.pub.Publish(ctx, events...)
+}
+
+func messageIDs(msgs []model.OutboxMessage) []int64 {
+	ids := make([]int64, len(msgs))
+	for i, m := range msgs {
+		ids[i] = m.ID
+	}
+	return ids
+}
+
+func (r *OutboxRelay) nextBackoff(prev time.Duration) time.Duration {
+	if prev < r.MinBackoff {
+		return r.MinBackoff
+	}
+	if next := prev * 2; next < r.MaxBackoff {
+		return next
+	}
+	return r.MaxBackoff
+}
@@ -0,0 +1,155 @@
+package messaging
+
+import (
+	"context"
+	"customer-api/pkg/event"
+	"customer-api/pkg/model"
+	"customer-api/pkg/repository"
+	"encoding/json"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// fakeOutbox keeps messages in id order and claims like the real table:
+// the oldest unsent, unparked messages, one claim at a time.
+type fakeOutbox struct {
+	msgs    []model.OutboxMessage
+	claimed bool
+}
+
+func (f *fakeOutbox) WithTx(*gorm.DB) repository.OutboxRepository   { return f }
+func (f *fakeOutbox) Add(context.Context, ...event.Event) error     { return nil }
+func (f *fakeOutbox) Unclaim(_ context.Context, ids []int64) error  { return f.settle(ids, nil) }
+func (f *fakeOutbox) MarkSent(_ context.Context, ids []int64) error { return f.settle(ids, f.sent) }
+func (f *fakeOutbox) Park(_ context.Context, id int64, _ error) error {
+	return f.settle([]int64{id}, f.park)
+}
+func (f *fakeOutbox) Release(_ context.Context, ids []int64, _ error) error {
+	return f.settle(ids, func(m *model.OutboxMessage) { m.Attempts++ })
+}
+
+func (f *fakeOutbox) Claim(_ context.Context, limit int, _ time.Duration) ([]model.OutboxMessage, error) {
+	if f.claimed {
+		return nil, nil
+	}
+	var batch []model.OutboxMessage
+	for _, m := range f.msgs {
+		if m.SentAt == nil && m.FailedAt == nil && len(batch) < limit {
+			batch = append(batch, m)
+		}
+	}
+	f.claimed = len(batch) > 0
+	return batch, nil
+}
+
+func (f *fakeOutbox) sent(m *model.OutboxMessage) {
+	now := time.Now()
+	m.SentAt = &now
+}
+
+func (f *fakeOutbox) park(m *model.OutboxMessage) {
+	now := time.Now()
+	m.Attempts++
+	m.FailedAt = &now
+}
+
+// settle applies fn to the messages with ids; every call ends the claim,
+// as the relay settles a whole batch before claiming again.
+func (f *fakeOutbox) settle(ids []int64, fn func(*model.OutboxMessage)) error {
+	f.claimed = false
+	for i := range f.msgs {
+		if fn != nil && slices.Contains(ids, f.msgs[i].ID) {
+			fn(&f.msgs[i])
+		}
+	}
+	return nil
+}
+
+// fakePublisher fails every publish that includes a rejected event.
+type fakePublisher struct {
+	reject    map[string]bool
+	published []string
+}
+
+func (p *fakePublisher) Publish(_ context.Context, events ...event.Event) error {
+	for _, e := range events {
+		if p.reject[e.Type] {
+			return errors.New("message too large")
+		}
+	}
+	for _, e := range events {
+		p.published = append(p.published, e.Type)
+	}
+	return nil
+}
+
+func outboxMessage(t *testing.T, id int64, typ string) model.OutboxMessage {
+	t.Helper()
+	payload, err := json.Marshal(event.Event{ID: uuid.New(), Type: typ})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return model.OutboxMessage{ID: id, Type: typ, Topic: "test.events", Payload: string(payload)}
+}
+
+func TestRelayParksMessageThatKeepsFailing(t *testing.T) {
+	repo := &fakeOutbox{msgs: []model.OutboxMessage{
+		outboxMessage(t, 1, "first"),
+		outboxMessage(t, 2, "bad"),
+		outboxMessage(t, 3, "third"),
+		outboxMessage(t, 4, "fourth"),
+		{ID: 5, Type: "garbled", Topic: "test.events", Payload: "{"},
+		outboxMessage(t, 6, "sixth"),
+	}}
+	pub := &fakePublisher{reject: map[string]bool{"bad": true}}
+
+	relay := NewOutboxRelay(repo, pub)
+	relay.MaxAttempts = 3
+
+	// each bad message fails until it is parked; those errors only set
+	// the backoff in Run
+	for range 10 {
+		_, _ = relay.relay(context.Background())
+	}
+
+	if want := []string{"first", "third", "fourth", "sixth"}; !slices.Equal(pub.published, want) {
+		t.Errorf("published %v, want %v", pub.published, want)
+	}
+	for _, m := range repo.msgs {
+		switch m.Type {
+		case "bad", "garbled":
+			if m.FailedAt == nil || m.SentAt != nil || m.Attempts != relay.MaxAttempts {
+				t.Errorf("message %d: failedAt %v, sentAt %v, attempts %d; want parked after %d attempts",
+					m.ID, m.FailedAt, m.SentAt, m.Attempts, relay.MaxAttempts)
+			}
+		default:
+			if m.SentAt == nil || m.Attempts != 0 {
+				t.Errorf("message %d: sentAt %v, attempts %d; want sent at the first try", m.ID, m.SentAt, m.Attempts)
+			}
+		}
+	}
+}
+
+func TestRelayRetriesBeforeParking(t *testing.T) {
+	repo := &fakeOutbox{msgs: []model.OutboxMessage{outboxMessage(t, 1, "bad"), outboxMessage(t, 2, "next")}}
+	pub := &fakePublisher{reject: map[string]bool{"bad": true}}
+	relay := NewOutboxRelay(repo, pub)
+	relay.MaxAttempts = 3
+
+	for attempt := 1; attempt < relay.MaxAttempts; attempt++ {
+		if _, err := relay.relay(context.Background()); err == nil {
+			t.Fatalf("attempt %d: relay succeeded, want the failure reported for backoff", attempt)
+		}
+		if m := repo.msgs[0]; m.Attempts != attempt || m.FailedAt != nil {
+			t.Fatalf("attempt %d: attempts %d, failedAt %v", attempt, m.Attempts, m.FailedAt)
+		}
+		if repo.msgs[1].SentAt != nil {
+			t.Fatalf("attempt %d: message behind a retried one was sent", attempt)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package messaging
+
+import (
+	"context"
+	"customer-api/pkg/event"
+	"customer-api/pkg/metrics"
+	"customer-api/pkg/tracing"
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// KafkaPublisher publishes domain events, keyed by aggregate id so every
+// change to one entity lands on the same partition in order.
+type KafkaPublisher struct {
+	writer  *kafka.Writer
+	brokers []string
+}
+
+func NewKafkaPublisher(brokers []string) *KafkaPublisher {
+	return &KafkaPublisher{
+		writer: &kafka.Writer{
+			Addr:                   kafka.TCP(brokers...),
+			Balancer:               &kafka.Hash{},
+			RequiredAcks:           kafka.RequireAll,
+			BatchTimeout:           10 * time.Millisecond,
+			AllowAutoTopicCreation: true,
+		},
+		brokers: brokers,
+	}
+}
+
+// Publish implements event.Publisher.
+func (p *KafkaPublisher) Publish(ctx context.Context, events ...event.Event) error {
+	msgs := make([]kafka.Message, 0, len(events))
+	spans := make([]trace.Span, 0, len(events))
+	for _, e := range events {
+		value, err := json.Marshal(e)
+		if err != nil {
+			return err
+		}
+		msg := kafka.Message{
+			Topic: e.Topic(),
+			Key:   []byte(e.AggregateID.String()),
+			Value: value,
+			Headers: []kafka.Header{
+				{Key: "event-type", Value: []byte(e.Type)},
+				{Key: "event-version", Value: []byte(strconv.Itoa(e.Version))},
+			},
+		}
+		_, span := tracing.StartPublish(ctx, msg.Topic, &msg)
+		msgs = append(msgs, msg)
+		spans = append(spans, span)
+	}
+	err := p.writer.WriteMessages(ctx, msgs...)
+	for i, m := range msgs {
+		tracing.Finish(spans[i], err)
+		metrics.Published(m.Topic, 1, err)
+	}
+	return err
+}
+
+// Ping reports whether at least one broker accepts a connection.
+func (p *KafkaPublisher) Ping(ctx context.Context) error {
+	var err error
+	for _, broker := range p.brokers {
+		var conn *kafka.Conn
+		if conn, err = kafka.DialContext(ctx, "tcp", broker); err == nil {
+			return conn.Close()
+		}
+	}
+	return err
+}
+
+func (p *KafkaPublisher) Close() error {
+	return p.writer.Close()
+}
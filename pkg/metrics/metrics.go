@@ -0,0 +1,118 @@
+// Package metrics holds the Prometheus collectors of the service and the
+// hooks that feed them.
+package metrics
+
+import (
+	"database/sql"
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "customer_api"
+
+// Results of a Kafka publish or of consuming one message.
+const (
+	ResultOK           = "ok"
+	ResultError        = "error"
+	ResultDeadLettered = "dead_lettered"
+	ResultDropped      = "dropped"
+)
+
+var (
+	httpRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "HTTP requests by method, route template and status.",
+	}, []string{"method", "route", "status"})
+
+	httpDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "HTTP request latency by method, route template and status.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	dbQueryDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "db_query_duration_seconds",
+		Help:      "GORM query latency by operation, table and result.",
+		Buckets:   []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+	}, []string{"operation", "table", "result"})
+
+	kafkaPublished = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "kafka_published_messages_total",
+		Help:      "Messages written to Kafka by topic and result.",
+	}, []string{"topic", "result"})
+
+	outboxParked = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "outbox_parked_messages_total",
+		Help:      "Outbox messages set aside after failing to publish too often, by topic.",
+	}, []string{"topic"})
+
+	consumerMessages = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "kafka_consumer_messages_total",
+		Help:      "Handler attempts of the Kafka consumer by topic and result.",
+	}, []string{"topic", "result"})
+
+	consumerDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "kafka_consumer_handle_duration_seconds",
+		Help:      "Time spent in the message handler by topic.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"topic"})
+
+	consumerLag = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "kafka_consumer_lag",
+		Help:      "Messages behind the high watermark, per topic and partition, as of the last message read.",
+	}, []string{"topic", "partition"})
+)
+
+// Handler serves the registered metrics in the Prometheus text format.
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
+
+// RegisterDBStats exports the connection pool statistics of db.
+func RegisterDBStats(db *sql.DB) error {
+	return prometheus.Register(collectors.NewDBStatsCollector(db, "customer_api"))
+}
+
+// Published counts n messages written to topic.
+func Published(topic string, n int, err error) {
+	kafkaPublished.WithLabelValues(topic, result(err)).Add(float64(n))
+}
+
+// OutboxParked counts one outbox message for topic that was set aside.
+func OutboxParked(topic string) {
+	outboxParked.WithLabelValues(topic).Inc()
+}
+
+// Consumed counts one handler attempt with result on topic.
+func Consumed(topic, result string) {
+	consumerMessages.WithLabelValues(topic, result).Inc()
+}
+
+// HandleDuration records how long the handler took for a message of topic.
+func HandleDuration(topic string, seconds float64) {
+	consumerDuration.WithLabelValues(topic).Observe(seconds)
+}
+
+// Lag records how far partition of topic is behind its high watermark.
+func Lag(topic, partition string, lag int64) {
+	consumerLag.WithLabelValues(topic, partition).Set(float64(lag))
+}
+
+func result(err error) string {
+	if err != nil {
+		return ResultError
+	}
+	return ResultOK
+}
@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS outbox_messages;
+DROP TABLE IF EXISTS interactions;
+DROP TABLE IF EXISTS feedbacks;
+DROP TABLE IF EXISTS products;
+DROP TABLE IF EXISTS customers;
@@ -0,0 +1,62 @@
+CREATE TABLE IF NOT EXISTS customers (
+    id         uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    name       varchar(255) NOT NULL,
+    email      varchar(255) NOT NULL,
+    phone      varchar(50),
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_customers_email ON customers (email);
+CREATE INDEX IF NOT EXISTS idx_customers_deleted_at ON customers (deleted_at);
+
+CREATE TABLE IF NOT EXISTS products (
+    id         uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    name       varchar(100) NOT NULL,
+    category   varchar(50),
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz
+);
+CREATE INDEX IF NOT EXISTS idx_products_deleted_at ON products (deleted_at);
+
+CREATE TABLE IF NOT EXISTS feedbacks (
+    id          uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    customer_id uuid NOT NULL REFERENCES customers (id),
+    product_id  uuid NOT NULL REFERENCES products (id),
+    rating      bigint NOT NULL CHECK (rating BETWEEN 1 AND 5),
+    comment     text,
+    created_at  timestamptz,
+    updated_at  timestamptz,
+    deleted_at  timestamptz
+);
+CREATE INDEX IF NOT EXISTS idx_feedbacks_customer_id ON feedbacks (customer_id);
+CREATE INDEX IF NOT EXISTS idx_feedbacks_product_id ON feedbacks (product_id);
+CREATE INDEX IF NOT EXISTS idx_feedbacks_deleted_at ON feedbacks (deleted_at);
+
+CREATE TABLE IF NOT EXISTS interactions (
+    id          uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    customer_id uuid NOT NULL REFERENCES customers (id),
+    channel     varchar(50),
+    description text,
+    created_at  timestamptz,
+    updated_at  timestamptz,
+    deleted_at  timestamptz
+);
+CREATE INDEX IF NOT EXISTS idx_interactions_customer_id ON interactions (customer_id);
+CREATE INDEX IF NOT EXISTS idx_interactions_deleted_at ON interactions (deleted_at);
+
+CREATE TABLE IF NOT EXISTS outbox_messages (
+    id         bigserial PRIMARY KEY,
+    event_id   uuid NOT NULL,
+    type       varchar(100) NOT NULL,
+    topic      varchar(255) NOT NULL,
+    key        varchar(255),
+    payload    jsonb NOT NULL,
+    attempts   bigint NOT NULL DEFAULT 0,
+    last_error text,
+    created_at timestamptz,
+    sent_at    timestamptz
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_outbox_messages_event_id ON outbox_messages (event_id);
+CREATE INDEX IF NOT EXISTS idx_outbox_messages_sent_at ON outbox_messages (sent_at);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_customers_phone_trgm;
+DROP INDEX IF EXISTS idx_customers_email_trgm;
+DROP INDEX IF EXISTS idx_customers_name_trgm;
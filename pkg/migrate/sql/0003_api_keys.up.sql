@@ -0,0 +1,14 @@
+CREATE TABLE api_keys (
+    id           uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    name         varchar(100) NOT NULL,
+    prefix       varchar(16) NOT NULL,
+    hash         varchar(64) NOT NULL,
+    scopes       jsonb NOT NULL DEFAULT '[]',
+    created_by   varchar(255),
+    expires_at   timestamptz,
+    last_used_at timestamptz,
+    revoked_at   timestamptz,
+    created_at   timestamptz,
+    updated_at   timestamptz
+);
+CREATE UNIQUE INDEX idx_api_keys_prefix ON api_keys (prefix);
@@ -0,0 +1,30 @@
+CREATE TABLE audit_logs (
+    id          uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    actor       varchar(255) NOT NULL,
+    action      varchar(20) NOT NULL,
+    entity_type varchar(50) NOT NULL,
+    entity_id   uuid NOT NULL,
+    before      jsonb,
+    after       jsonb,
+    changes     jsonb,
+    request_id  varchar(128),
+    created_at  timestamptz NOT NULL DEFAULT now()
+);
+CREATE INDEX idx_audit_logs_entity ON audit_logs (entity_type, entity_id, created_at DESC);
+CREATE INDEX idx_audit_logs_actor ON audit_logs (actor, created_at DESC);
+CREATE INDEX idx_audit_logs_created_at ON audit_logs (created_at DESC, id DESC);
+
+-- the audit trail is append-only
+CREATE FUNCTION audit_logs_immutable() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'audit_logs is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER audit_logs_no_update_delete
+    BEFORE UPDATE OR DELETE ON audit_logs
+    FOR EACH ROW EXECUTE FUNCTION audit_logs_immutable();
+
+CREATE TRIGGER audit_logs_no_truncate
+    BEFORE TRUNCATE ON audit_logs
+    FOR EACH STATEMENT EXECUTE FUNCTION audit_logs_immutable();
@@ -0,0 +1,7 @@
+CREATE OR REPLACE FUNCTION audit_logs_immutable() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'audit_logs is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+ALTER TABLE customers DROP COLUMN IF EXISTS erased_at;
@@ -0,0 +1,18 @@
+ALTER TABLE customers ADD COLUMN erased_at timestamptz;
+
+-- PDPA erasure may strip personal data from the snapshots of an erased
+-- customer's entries, and nothing else. It opts in per transaction with
+-- SET LOCAL customer_api.erasure = 'on'.
+CREATE OR REPLACE FUNCTION audit_logs_immutable() RETURNS trigger AS $$
+BEGIN
+    IF TG_OP = 'UPDATE'
+       AND current_setting('customer_api.erasure', true) = 'on'
+       AND (NEW.id, NEW.actor, NEW.action, NEW.entity_type, NEW.entity_id, NEW.request_id, NEW.created_at)
+           IS NOT DISTINCT FROM
+           (OLD.id, OLD.actor, OLD.action, OLD.entity_type, OLD.entity_id, OLD.request_id, OLD.created_at)
+    THEN
+        RETURN NEW;
+    END IF;
+    RAISE EXCEPTION 'audit_logs is append-only';
+END;
+$$ LANGUAGE plpgsql;
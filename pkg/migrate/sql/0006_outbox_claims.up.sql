@@ -0,0 +1,4 @@
+-- The relay claims a batch in a short transaction and publishes it after
+-- committing. A claim lapses at claimed_until, so a batch whose relay died
+-- is picked up again.
+ALTER TABLE outbox_messages ADD COLUMN claimed_until timestamptz;
@@ -0,0 +1,3 @@
+-- A message that keeps failing to publish is parked at failed_at so the
+-- relay moves past it; clearing failed_at and attempts queues it again.
+ALTER TABLE outbox_messages ADD COLUMN failed_at timestamptz;
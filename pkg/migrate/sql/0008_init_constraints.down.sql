@@ -0,0 +1,3 @@
+-- On fresh databases these constraints belong to 0001_init, so reverting
+-- this step keeps them.
+SELECT 1;
@@ -0,0 +1,33 @@
+-- Databases created by AutoMigrate before versioned migrations recorded
+-- 0001_init without its constraints, as CREATE TABLE IF NOT EXISTS skipped
+-- their tables. Replace AutoMigrate's foreign keys, which set NULL on
+-- delete, with the ones 0001 creates, and add whatever is missing, so
+-- fresh and upgraded databases end up with the same schema. Rows that
+-- break a constraint make this migration fail; fix them and run it again.
+DO $$
+BEGIN
+    ALTER TABLE feedbacks DROP CONSTRAINT IF EXISTS fk_customers_feedbacks;
+    ALTER TABLE feedbacks DROP CONSTRAINT IF EXISTS fk_products_feedbacks;
+    ALTER TABLE interactions DROP CONSTRAINT IF EXISTS fk_customers_interactions;
+
+    IF NOT EXISTS (SELECT 1 FROM pg_constraint
+                   WHERE conrelid = 'feedbacks'::regclass AND conname = 'feedbacks_customer_id_fkey') THEN
+        ALTER TABLE feedbacks ADD CONSTRAINT feedbacks_customer_id_fkey
+            FOREIGN KEY (customer_id) REFERENCES customers (id);
+    END IF;
+    IF NOT EXISTS (SELECT 1 FROM pg_constraint
+                   WHERE conrelid = 'feedbacks'::regclass AND conname = 'feedbacks_product_id_fkey') THEN
+        ALTER TABLE feedbacks ADD CONSTRAINT feedbacks_product_id_fkey
+            FOREIGN KEY (product_id) REFERENCES products (id);
+    END IF;
+    IF NOT EXISTS (SELECT 1 FROM pg_constraint
+                   WHERE conrelid = 'feedbacks'::regclass AND conname = 'feedbacks_rating_check') THEN
+        ALTER TABLE feedbacks ADD CONSTRAINT feedbacks_rating_check
+            CHECK (rating BETWEEN 1 AND 5);
+    END IF;
+    IF NOT EXISTS (SELECT 1 FROM pg_constraint
+                   WHERE conrelid = 'interactions'::regclass AND conname = 'interactions_customer_id_fkey') THEN
+        ALTER TABLE interactions ADD CONSTRAINT interactions_customer_id_fkey
+            FOREIGN KEY (customer_id) REFERENCES customers (id);
+    END IF;
+END $$;
@@ -0,0 +1,20 @@
+DROP FUNCTION IF EXISTS erase_audit_personal_data(uuid);
+
+CREATE OR REPLACE FUNCTION audit_logs_immutable() RETURNS trigger AS $$
+BEGIN
+    IF TG_OP = 'UPDATE'
+       AND current_setting('customer_api.erasure', true) = 'on'
+       AND (NEW.id, NEW.actor, NEW.action, NEW.entity_type, NEW.entity_id, NEW.request_id, NEW.created_at)
+           IS NOT DISTINCT FROM
+           (OLD.id, OLD.actor, OLD.action, OLD.entity_type, OLD.entity_id, OLD.request_id, OLD.created_at)
+    THEN
+        RETURN NEW;
+    END IF;
+    RAISE EXCEPTION 'audit_logs is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+REVOKE ALL ON audit_logs, customers, feedbacks, interactions FROM customer_api_erasure;
+-- roles are cluster wide; this fails while another database still grants
+-- customer_api_erasure anything
+DROP ROLE IF EXISTS customer_api_erasure;
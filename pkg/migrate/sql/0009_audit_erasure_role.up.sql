@@ -0,0 +1,57 @@
+-- Only erase_audit_personal_data may rewrite audit snapshots. The trigger
+-- checks current_user, which inside the SECURITY DEFINER function is the
+-- customer_api_erasure role that owns it, so a plain SET LOCAL no longer
+-- opens the table. The application must not be a member of that role.
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM pg_roles WHERE rolname = 'customer_api_erasure') THEN
+        CREATE ROLE customer_api_erasure NOLOGIN;
+    END IF;
+END;
+$$;
+
+GRANT SELECT, UPDATE ON audit_logs TO customer_api_erasure;
+GRANT SELECT ON customers, feedbacks, interactions TO customer_api_erasure;
+
+CREATE OR REPLACE FUNCTION audit_logs_immutable() RETURNS trigger AS $$
+BEGIN
+    IF TG_OP = 'UPDATE'
+       AND current_user = 'customer_api_erasure'
+       AND (NEW.id, NEW.actor, NEW.action, NEW.entity_type, NEW.entity_id, NEW.request_id, NEW.created_at)
+           IS NOT DISTINCT FROM
+           (OLD.id, OLD.actor, OLD.action, OLD.entity_type, OLD.entity_id, OLD.request_id, OLD.created_at)
+    THEN
+        RETURN NEW;
+    END IF;
+    RAISE EXCEPTION 'audit_logs is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+-- Strips personal data from the audit entries about an erased customer,
+-- their feedback and their interactions. Customers that are not erased are
+-- refused, so the function cannot be used to rewrite anyone else's history.
+-- The keys match repository.PersonalFields.
+CREATE OR REPLACE FUNCTION erase_audit_personal_data(p_customer uuid) RETURNS void
+    SECURITY DEFINER
+    SET search_path = public, pg_temp
+AS $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM customers WHERE id = p_customer AND erased_at IS NOT NULL) THEN
+        RAISE EXCEPTION 'customer % is not erased', p_customer;
+    END IF;
+    UPDATE audit_logs
+       SET before  = before  - '{name,email,phone,comment,description}'::text[],
+           after   = after   - '{name,email,phone,comment,description}'::text[],
+           changes = changes - '{name,email,phone,comment,description}'::text[]
+     WHERE (entity_type = 'customer' AND entity_id = p_customer)
+        OR (entity_type = 'feedback' AND entity_id IN (SELECT id FROM feedbacks WHERE customer_id = p_customer))
+        OR (entity_type = 'interaction' AND entity_id IN (SELECT id FROM interactions WHERE customer_id = p_customer));
+END;
+$$ LANGUAGE plpgsql;
+
+-- the migrating user needs the role only long enough to hand it the function
+GRANT customer_api_erasure TO CURRENT_USER;
+ALTER FUNCTION erase_audit_personal_data(uuid) OWNER TO customer_api_erasure;
+REVOKE customer_api_erasure FROM CURRENT_USER;
+REVOKE ALL ON FUNCTION erase_audit_personal_data(uuid) FROM PUBLIC;
+GRANT EXECUTE ON FUNCTION erase_audit_personal_data(uuid) TO CURRENT_USER;
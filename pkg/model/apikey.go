@@ -0,0 +1,23 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// APIKey lets a service client call the API without a user. Only a hash of
+// the key is stored; Prefix is the public part used to look it up.
+type APIKey struct {
+	ID         uuid.UUID  `json:"id" gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	Name       string     `json:"name" gorm:"size:100;not null"`
+	Prefix     string     `json:"prefix" gorm:"size:16;not null;uniqueIndex"`
+	Hash       string     `json:"-" gorm:"size:64;not null"`
+	Scopes     []string   `json:"scopes" gorm:"serializer:json;type:jsonb;not null"`
+	CreatedBy  string     `json:"createdBy" gorm:"size:255"`
+	ExpiresAt  *time.Time `json:"expiresAt"`
+	LastUsedAt *time.Time `json:"lastUsedAt"`
+	RevokedAt  *time.Time `json:"revokedAt"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	UpdatedAt  time.Time  `json:"updatedAt"`
+}
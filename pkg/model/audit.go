@@ -0,0 +1,22 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// AuditLog records one change to an entity. Rows are never updated or
+// deleted; the database rejects both.
+type AuditLog struct {
+	ID         uuid.UUID `json:"id" gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	Actor      string    `json:"actor" gorm:"size:255;not null"`
+	Action     string    `json:"action" gorm:"size:20;not null"`
+	EntityType string    `json:"entityType" gorm:"size:50;not null"`
+	EntityID   uuid.UUID `json:"entityId" gorm:"type:uuid;not null"`
+	Before     JSON      `json:"before,omitempty" gorm:"type:jsonb"`
+	After      JSON      `json:"after,omitempty" gorm:"type:jsonb"`
+	Changes    JSON      `json:"changes,omitempty" gorm:"type:jsonb"`
+	RequestID  string    `json:"requestId" gorm:"size:128"`
+	CreatedAt  time.Time `json:"createdAt"`
+}
@@ -15,6 +15,9 @@ type Customer struct {
 	CreatedAt time.Time      `json:"createdAt"`
 	UpdatedAt time.Time      `json:"updatedAt"`
 	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
+	// ErasedAt is set once the customer's personal data has been
+	// anonymised on request; the row is soft deleted at the same time.
+	ErasedAt *time.Time `json:"erasedAt,omitempty"`
 
 	Feedbacks    []Feedback    `json:"feedbacks" gorm:"foreignKey:CustomerID;constraint:OnDelete:SET NULL;"`
 	Interactions []Interaction `json:"interactions" gorm:"foreignKey:CustomerID;constraint:OnDelete:SET NULL;"`
@@ -0,0 +1,27 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// OutboxMessage is a domain event waiting to be relayed to Kafka. It is
+// written in the same transaction as the change it describes.
+type OutboxMessage struct {
+	ID        int64     `gorm:"primaryKey;autoIncrement"`
+	EventID   uuid.UUID `gorm:"type:uuid;not null;uniqueIndex"`
+	Type      string    `gorm:"size:100;not null"`
+	Topic     string    `gorm:"size:255;not null"`
+	Key       string    `gorm:"size:255"`
+	Payload   string    `gorm:"type:jsonb;not null"`
+	Attempts  int       `gorm:"not null;default:0"`
+	LastError string    `gorm:"type:text"`
+	CreatedAt time.Time
+	SentAt    *time.Time `gorm:"index"`
+	// ClaimedUntil is when a relay's claim on the unsent message lapses.
+	ClaimedUntil *time.Time
+	// FailedAt is when the message was parked after failing too often;
+	// the relay no longer picks it up.
+	FailedAt *time.Time
+}
@@ -0,0 +1,44 @@
+package openapi
+
+import (
+	_ "embed"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	swaggerFiles "github.com/swaggo/files"
+)
+
+//go:embed ui/index.html
+var indexHTML string
+
+// Handler serves the document as JSON, encoded once up front.
+func (d *Document) Handler() gin.HandlerFunc {
+	body, err := json.MarshalIndent(d, "", "  ")
+	if err != nil {
+		panic("openapi: encode document: " + err.Error())
+	}
+	return func(c *gin.Context) {
+		c.Data(http.StatusOK, "application/json", body)
+	}
+}
+
+// UI serves Swagger UI for the document at specURL. Mount it on a
+// wildcard route ending in *filepath, e.g. /docs/*filepath.
+func UI(specURL string) gin.HandlerFunc {
+	index := []byte(strings.ReplaceAll(indexHTML, "{{SPEC_URL}}", specURL))
+	assets := http.FileServer(swaggerFiles.HTTP)
+
+	return func(c *gin.Context) {
+		file := c.Param("filepath")
+		if file == "/" || file == "/index.html" {
+			c.Data(http.StatusOK, "text/html; charset=utf-8", index)
+			return
+		}
+
+		req := c.Request.Clone(c.Request.Context())
+		req.URL.Path = file
+		assets.ServeHTTP(c.Writer, req)
+	}
+}
@@ -0,0 +1,314 @@
+// Package openapi builds the service's OpenAPI document from a route table
+// and the Go request and response types, and serves it with Swagger UI.
+package openapi
+
+import (
+	"customer-api/pkg/problem"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Version is the OpenAPI version of the documents built here.
+const Version = "3.1.0"
+
+type Document struct {
+	OpenAPI    string              `json:"openapi"`
+	Info       Info                `json:"info"`
+	Paths      map[string]PathItem `json:"paths"`
+	Components Components          `json:"components"`
+
+	// routes are the documented "METHOD /gin/:path" keys, and optional
+	// those that may legitimately be missing from the router.
+	routes   map[string]bool
+	optional map[string]bool
+}
+
+type Info struct {
+	Title       string `json:"title"`
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+}
+
+// PathItem maps a lower case HTTP method to its operation.
+type PathItem map[string]*Operation
+
+type Operation struct {
+	OperationID string                `json:"operationId,omitempty"`
+	Summary     string                `json:"summary,omitempty"`
+	Description string                `json:"description,omitempty"`
+	Tags        []string              `json:"tags,omitempty"`
+	Parameters  []Parameter           `json:"parameters,omitempty"`
+	RequestBody *RequestBody          `json:"requestBody,omitempty"`
+	Responses   map[string]Response   `json:"responses"`
+	Security    []map[string][]string `json:"security"`
+	Permission  string                `json:"x-permission,omitempty"`
+}
+
+type Parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Required    bool    `json:"required,omitempty"`
+	Description string  `json:"description,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+type RequestBody struct {
+	Required bool                 `json:"required"`
+	Content  map[string]MediaType `json:"content"`
+}
+
+type MediaType struct {
+	Schema *Schema `json:"schema,omitempty"`
+}
+
+type Response struct {
+	Description string               `json:"description"`
+	Content     map[string]MediaType `json:"content,omitempty"`
+}
+
+type Components struct {
+	Schemas         map[string]*Schema        `json:"schemas"`
+	SecuritySchemes map[string]SecurityScheme `json:"securitySchemes"`
+}
+
+type SecurityScheme struct {
+	Type         string `json:"type"`
+	Scheme       string `json:"scheme,omitempty"`
+	BearerFormat string `json:"bearerFormat,omitempty"`
+	In           string `json:"in,omitempty"`
+	Name         string `json:"name,omitempty"`
+	Description  string `json:"description,omitempty"`
+}
+
+// Route documents one registered route.
+type Route struct {
+	Method string
+	// Path uses gin syntax; every :param is a UUID.
+	Path    string
+	Tag     string
+	Summary string
+
+	// Permission is required to call the route; empty means public.
+	Permission string
+	Query      []Parameter
+	// Paged adds the limit and cursor parameters of list endpoints.
+	Paged bool
+
+	// Body and Response are zero values of the request and response types.
+	// A nil Response means the route answers without a body.
+	Body any
+	// Uploads lists media types of a raw file body, used instead of Body.
+	Uploads     []string
+	Response    any
+	Status      int
+	ContentType string
+	// Downloads lists media types of a file response, used instead of
+	// Response.
+	Downloads []string
+
+	// Errors lists statuses beyond those implied by the route's shape.
+	Errors []int
+	// Optional routes are registered only when a feature is enabled.
+	Optional bool
+}
+
+// Query describes a string query parameter.
+func Query(name, description string) Parameter {
+	return Parameter{Name: name, In: "query", Description: description, Schema: &Schema{Type: "string"}}
+}
+
+// QueryUUID describes a UUID query parameter.
+func QueryUUID(name, description string) Parameter {
+	p := Query(name, description)
+	p.Schema.Format = "uuid"
+	return p
+}
+
+var pageParams = []Parameter{
+	{Name: "limit", In: "query", Description: "Page size, 1-100 (default 10)", Schema: &Schema{Type: "integer"}},
+	Query("cursor", "nextCursor of the previous page"),
+}
+
+// Build documents routes. It panics on a route documented twice, which is
+// a programming error in the route table.
+func Build(info Info, routes []Route) *Document {
+	g := newGenerator()
+	// Problem encodes itself to inline its extensions, so it is described
+	// field by field rather than as an opaque JSON value
+	g.schemas["Problem"] = g.object(reflect.TypeFor[problem.Problem]())
+	problemRef := &Schema{Ref: "#/components/schemas/Problem"}
+
+	doc := &Document{
+		OpenAPI: Version,
+		Info:    info,
+		Paths:   map[string]PathItem{},
+		Components: Components{
+			Schemas: g.schemas,
+			SecuritySchemes: map[string]SecurityScheme{
+				"bearer": {Type: "http", Scheme: "bearer", BearerFormat: "JWT or API key",
+					Description: "A user JWT, or an API key (cak_…)"},
+				"apiKey": {Type: "apiKey", In: "header", Name: "X-API-Key"},
+			},
+		},
+		routes:   map[string]bool{},
+		optional: map[string]bool{},
+	}
+
+	for _, r := range routes {
+		key := r.Method + " " + r.Path
+		if doc.routes[key] {
+			panic("openapi: route documented twice: " + key)
+		}
+		doc.routes[key] = true
+		doc.optional[key] = r.Optional
+
+		path, params := openAPIPath(r.Path)
+		item := doc.Paths[path]
+		if item == nil {
+			item = PathItem{}
+			doc.Paths[path] = item
+		}
+		item[strings.ToLower(r.Method)] = r.operation(g, params, problemRef)
+	}
+	return doc
+}
+
+func (r Route) operation(g *generator, params []Parameter, problemRef *Schema) *Operation {
+	op := &Operation{
+		OperationID: operationID(r.Method, r.Path),
+		Summary:     r.Summary,
+		Tags:        []string{r.Tag},
+		Parameters:  append(params, r.Query...),
+		Responses:   map[string]Response{},
+		Permission:  r.Permission,
+		// an empty list marks a public operation
+		Security: []map[string][]string{},
+	}
+	if r.Paged {
+		op.Parameters = append(op.Parameters, pageParams...)
+	}
+
+	errs := map[int]bool{http.StatusInternalServerError: true}
+	if len(params) > 0 {
+		errs[http.StatusBadRequest] = true
+		errs[http.StatusNotFound] = true
+	}
+	if len(r.Query) > 0 || r.Paged {
+		errs[http.StatusBadRequest] = true
+	}
+	if r.Body != nil {
+		errs[http.StatusBadRequest] = true
+		errs[http.StatusUnprocessableEntity] = true
+		op.RequestBody = &RequestBody{
+			Required: true,
+			Content:  map[string]MediaType{"application/json": {Schema: g.schemaOf(r.Body)}},
+		}
+	}
+	if len(r.Uploads) > 0 {
+		errs[http.StatusBadRequest] = true
+		errs[http.StatusUnprocessableEntity] = true
+		op.RequestBody = &RequestBody{Required: true, Content: map[string]MediaType{}}
+		for _, ct := range r.Uploads {
+			op.RequestBody.Content[ct] = MediaType{Schema: &Schema{Type: "string"}}
+		}
+	}
+	if r.Permission != "" {
+		op.Description = "Requires the `" + r.Permission + "` permission."
+		op.Security = []map[string][]string{{"bearer": {}}, {"apiKey": {}}}
+		errs[http.StatusUnauthorized] = true
+		errs[http.StatusForbidden] = true
+		errs[http.StatusTooManyRequests] = true
+	}
+	for _, status := range r.Errors {
+		errs[status] = true
+	}
+
+	status := r.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	ok := Response{Description: http.StatusText(status)}
+	if r.Response != nil {
+		ct := r.ContentType
+		if ct == "" {
+			ct = "application/json"
+		}
+		ok.Content = map[string]MediaType{ct: {Schema: g.schemaOf(r.Response)}}
+	}
+	if len(r.Downloads) > 0 {
+		ok.Content = map[string]MediaType{}
+		for _, ct := range r.Downloads {
+			ok.Content[ct] = MediaType{Schema: &Schema{Type: "string"}}
+		}
+	}
+	op.Responses[strconv.Itoa(status)] = ok
+
+	for status := range errs {
+		op.Responses[strconv.Itoa(status)] = Response{
+			Description: http.StatusText(status),
+			Content:     map[string]MediaType{problem.ContentType: {Schema: problemRef}},
+		}
+	}
+	return op
+}
+
+// openAPIPath turns /customers/:id into /customers/{id} and returns the
+// path parameters.
+func openAPIPath(path string) (string, []Parameter) {
+	var params []Parameter
+	segs := strings.Split(path, "/")
+	for i, seg := range segs {
+		if name, ok := strings.CutPrefix(seg, ":"); ok {
+			segs[i] = "{" + name + "}"
+			params = append(params, Parameter{
+				Name: name, In: "path", Required: true,
+				Schema: &Schema{Type: "string", Format: "uuid"},
+			})
+		}
+	}
+	return strings.Join(segs, "/"), params
+}
+
+// operationID derives a stable id such as getCustomersId from the route.
+func operationID(method, path string) string {
+	var b strings.Builder
+	b.WriteString(strings.ToLower(method))
+	for _, part := range strings.FieldsFunc(path, func(r rune) bool {
+		return r == '/' || r == ':' || r == '-' || r == '.'
+	}) {
+		b.WriteString(exported(part))
+	}
+	return b.String()
+}
+
+// Diff compares the document with the router. Undocumented lists
+// registered routes the document lacks; stale lists documented routes that
+// are not registered and not optional. Routes in skip are ignored.
+func (d *Document) Diff(routes gin.RoutesInfo, skip ...string) (undocumented, stale []string) {
+	ignored := make(map[string]bool, len(skip))
+	for _, k := range skip {
+		ignored[k] = true
+	}
+
+	registered := make(map[string]bool, len(routes))
+	for _, r := range routes {
+		key := r.Method + " " + r.Path
+		registered[key] = true
+		if !d.routes[key] && !ignored[key] {
+			undocumented = append(undocumented, key)
+		}
+	}
+	for key := range d.routes {
+		if !registered[key] && !d.optional[key] {
+			stale = append(stale, key)
+		}
+	}
+	sort.Strings(undocumented)
+	sort.Strings(stale)
+	return undocumented, stale
+}
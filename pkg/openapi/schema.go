@@ -0,0 +1,252 @@
+package openapi
+
+import (
+	"customer-api/pkg/validation"
+	"encoding"
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/google/uuid"
+)
+
+// Schema is the subset of JSON Schema that OpenAPI 3.0 and 3.1 share, so
+// one document suits both.
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	Enum                 []string           `json:"enum,omitempty"`
+	MinLength            *int               `json:"minLength,omitempty"`
+	MaxLength            *int               `json:"maxLength,omitempty"`
+	MinItems             *int               `json:"minItems,omitempty"`
+	MaxItems             *int               `json:"maxItems,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Maximum              *float64           `json:"maximum,omitempty"`
+}
+
+var (
+	timeType          = reflect.TypeFor[time.Time]()
+	uuidType          = reflect.TypeFor[uuid.UUID]()
+	jsonMarshalerType = reflect.TypeFor[json.Marshaler]()
+	textMarshalerType = reflect.TypeFor[encoding.TextMarshaler]()
+)
+
+// generator turns Go types into schemas the way encoding/json would encode
+// them. Named structs become components referenced by $ref.
+type generator struct {
+	schemas map[string]*Schema
+	names   map[reflect.Type]string
+}
+
+func newGenerator() *generator {
+	return &generator{
+		schemas: map[string]*Schema{},
+		names:   map[reflect.Type]string{},
+	}
+}
+
+// schemaOf returns the schema for the type of v, or nil for a nil v.
+func (g *generator) schemaOf(v any) *Schema {
+	if v == nil {
+		return nil
+	}
+	return g.schema(reflect.TypeOf(v))
+}
+
+func (g *generator) schema(t reflect.Type) *Schema {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch {
+	case t == timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case t == uuidType:
+		return &Schema{Type: "string", Format: "uuid"}
+	case implements(t, jsonMarshalerType):
+		// encodes itself; any JSON value
+		return &Schema{}
+	case implements(t, textMarshalerType):
+		return &Schema{Type: "string"}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: g.schema(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: g.schema(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return g.object(t)
+		}
+		return &Schema{Ref: "#/components/schemas/" + g.component(t)}
+	default:
+		return &Schema{}
+	}
+}
+
+// component registers the named struct t once and returns its name.
+func (g *generator) component(t reflect.Type) string {
+	if name, ok := g.names[t]; ok {
+		return name
+	}
+
+	name := typeName(t)
+	if _, taken := g.schemas[name]; taken {
+		name = exported(t.PkgPath()[strings.LastIndex(t.PkgPath(), "/")+1:]) + name
+	}
+	g.names[t] = name
+	// reserve the name before recursing so self references terminate
+	g.schemas[name] = &Schema{}
+	*g.schemas[name] = *g.object(t)
+	return name
+}
+
+func (g *generator) object(t reflect.Type) *Schema {
+	s := &Schema{Type: "object", Properties: map[string]*Schema{}}
+	g.fields(t, s)
+	return s
+}
+
+// fields adds the JSON fields of struct t to s, flattening embedded
+// structs like encoding/json does.
+func (g *generator) fields(t reflect.Type, s *Schema) {
+	for i := range t.NumField() {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+
+		if f.Anonymous && name == "" {
+			ft := f.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				g.fields(ft, s)
+				continue
+			}
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+
+		p := g.schema(f.Type)
+		if applyValidate(p, f.Tag.Get("validate")) {
+			s.Required = append(s.Required, name)
+		}
+		s.Properties[name] = p
+	}
+}
+
+// applyValidate copies the constraints of a validator tag onto s and
+// reports whether the field is required.
+func applyValidate(s *Schema, tag string) bool {
+	required := false
+	for _, rule := range strings.Split(tag, ",") {
+		key, param, _ := strings.Cut(rule, "=")
+		if key == "required" {
+			required = true
+		}
+		if s.Ref != "" {
+			// a $ref takes no sibling keywords in OpenAPI 3.0
+			continue
+		}
+		switch key {
+		case "email":
+			s.Format = "email"
+		case "uuid":
+			s.Format = "uuid"
+		case "oneof":
+			s.Enum = strings.Fields(param)
+		case "min", "max":
+			setBound(s, key == "min", param)
+		default:
+			if values, ok := validation.Enum(key); ok {
+				s.Enum = values
+			}
+		}
+	}
+	return required
+}
+
+// setBound sets a min or max rule, which bounds the length of strings, the
+// size of arrays and the value of numbers.
+func setBound(s *Schema, lower bool, param string) {
+	n, err := strconv.ParseFloat(param, 64)
+	if err != nil {
+		return
+	}
+	size := int(n)
+	switch s.Type {
+	case "string":
+		if lower {
+			s.MinLength = &size
+		} else {
+			s.MaxLength = &size
+		}
+	case "array":
+		if lower {
+			s.MinItems = &size
+		} else {
+			s.MaxItems = &size
+		}
+	case "integer", "number":
+		if lower {
+			s.Minimum = &n
+		} else {
+			s.Maximum = &n
+		}
+	}
+}
+
+func implements(t, iface reflect.Type) bool {
+	return t.Implements(iface) || reflect.PointerTo(t).Implements(iface)
+}
+
+// typeName names a component after its Go type; an instance of a generic
+// type such as Page[model.Customer] becomes CustomerPage.
+func typeName(t reflect.Type) string {
+	name := exported(t.Name())
+	base, arg, ok := strings.Cut(name, "[")
+	if !ok {
+		return name
+	}
+	arg = strings.TrimSuffix(arg, "]")
+	return exported(arg[strings.LastIndex(arg, ".")+1:]) + base
+}
+
+func exported(s string) string {
+	r := []rune(s)
+	if len(r) == 0 {
+		return s
+	}
+	r[0] = unicode.ToUpper(r[0])
+	return string(r)
+}
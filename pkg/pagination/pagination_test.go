@@ -0,0 +1,72 @@
+package pagination
+
+import (
+	"encoding/base64"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	tests := []Cursor{
+		{Key: "Alice", ID: uuid.New()},
+		{Key: "2026-01-02T03:04:05.123456Z", ID: uuid.New()},
+		{Key: "", ID: uuid.New()},
+		{Key: "ชื่อ/with+symbols=?&", ID: uuid.New()},
+	}
+	for _, want := range tests {
+		s := want.Encode()
+		got, err := Decode(s)
+		if err != nil {
+			t.Fatalf("Decode(%q): %v", s, err)
+		}
+		if *got != want {
+			t.Errorf("Decode(Encode(%+v)) = %+v", want, *got)
+		}
+	}
+}
+
+func TestDecodeRejectsTampering(t *testing.T) {
+	valid := Cursor{Key: "Alice", ID: uuid.New()}.Encode()
+	encode := func(s string) string { return base64.RawURLEncoding.EncodeToString([]byte(s)) }
+
+	tests := []struct {
+		name   string
+		cursor string
+	}{
+		{"not base64", "!!!"},
+		{"padded base64", base64.URLEncoding.EncodeToString([]byte(`{"k":"a","id":"` + uuid.NewString() + `"}`))},
+		{"truncated", valid[:len(valid)-4]},
+		{"not json", encode("hello")},
+		{"json array", encode(`["a"]`)},
+		{"missing id", encode(`{"k":"a"}`)},
+		{"nil id", encode(`{"k":"a","id":"` + uuid.Nil.String() + `"}`)},
+		{"malformed id", encode(`{"k":"a","id":"not-a-uuid"}`)},
+		{"wrong key type", encode(`{"k":1,"id":"` + uuid.NewString() + `"}`)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Decode(tt.cursor); !errors.Is(err, ErrInvalidCursor) {
+				t.Errorf("Decode(%q) error = %v, want ErrInvalidCursor", tt.cursor, err)
+			}
+		})
+	}
+}
+
+func TestWithDefaults(t *testing.T) {
+	tests := []struct {
+		limit, want int
+	}{
+		{-1, DefaultLimit},
+		{0, DefaultLimit},
+		{1, 1},
+		{MaxLimit, MaxLimit},
+		{MaxLimit + 1, MaxLimit},
+	}
+	for _, tt := range tests {
+		if got := (Params{Limit: tt.limit, Cursor: "c"}).WithDefaults(); got.Limit != tt.want || got.Cursor != "c" {
+			t.Errorf("Params{Limit: %d}.WithDefaults() = %+v, want limit %d", tt.limit, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,90 @@
+// Package problem writes error responses as RFC 7807 problem details.
+package problem
+
+import (
+	"customer-api/pkg/requestid"
+	"encoding/json"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ContentType is the media type of a problem response.
+const ContentType = "application/problem+json"
+
+// typePrefix turns a stable code into the problem's type URI.
+const typePrefix = "urn:customer-api:problem:"
+
+// Problem is an RFC 7807 problem detail. Code is the stable, machine readable
+// identifier clients should switch on; Title and Detail are for humans.
+type Problem struct {
+	Type      string            `json:"type"`
+	Title     string            `json:"title"`
+	Status    int               `json:"status"`
+	Detail    string            `json:"detail,omitempty"`
+	Instance  string            `json:"instance,omitempty"`
+	Code      string            `json:"code"`
+	RequestID string            `json:"requestId,omitempty"`
+	Fields    map[string]string `json:"fields,omitempty"`
+
+	// Extensions are extra members written next to the standard ones.
+	Extensions map[string]any `json:"-"`
+}
+
+// New returns a problem with the given status, code and detail.
+func New(status int, code, detail string) *Problem {
+	return &Problem{
+		Type:   typePrefix + code,
+		Title:  http.StatusText(status),
+		Status: status,
+		Detail: detail,
+		Code:   code,
+	}
+}
+
+// With adds an extension member and returns p.
+func (p *Problem) With(key string, value any) *Problem {
+	if p.Extensions == nil {
+		p.Extensions = map[string]any{}
+	}
+	p.Extensions[key] = value
+	return p
+}
+
+// MarshalJSON writes the extensions inline with the standard members.
+func (p Problem) MarshalJSON() ([]byte, error) {
+	type plain Problem
+	b, err := json.Marshal(plain(p))
+	if err != nil || len(p.Extensions) == 0 {
+		return b, err
+	}
+
+	m := make(map[string]any, len(p.Extensions))
+	if err := json.Unmarshal(b, &m); err != nil {
+		return nil, err
+	}
+	for k, v := range p.Extensions {
+		if _, ok := m[k]; !ok {
+			m[k] = v
+		}
+	}
+	return json.Marshal(m)
+}
+
+// Abort writes p as the response and stops the handler chain. The request
+// path and id are filled in so a reported problem can be traced back.
+func Abort(c *gin.Context, p *Problem) {
+	if p.Instance == "" {
+		p.Instance = c.Request.URL.Path
+	}
+	if p.RequestID == "" {
+		p.RequestID = requestid.FromContext(c.Request.Context())
+	}
+
+	body, err := json.Marshal(p)
+	if err != nil {
+		body = []byte(`{"type":"about:blank","status":500}`)
+	}
+	c.Abort()
+	c.Data(p.Status, ContentType, body)
+}
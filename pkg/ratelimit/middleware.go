@@ -0,0 +1,109 @@
+package ratelimit
+
+import (
+	"customer-api/pkg/auth"
+	"customer-api/pkg/logging"
+	"customer-api/pkg/problem"
+	"math"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Middleware limits each client to def, or to the override for the matched
+// route ("METHOD /route/:template"). Clients are keyed by API key or user
+// when authenticated and by IP otherwise, so it belongs after auth.
+func Middleware(store Store, def Limit, routes map[string]Limit) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := clientKey(c)
+		limit := def
+		if override, ok := routes[c.Request.Method+" "+c.FullPath()]; ok {
+			limit = override
+			key += "|" + c.Request.Method + " " + c.FullPath()
+		}
+		take(c, store, key, limit)
+	}
+}
+
+// IPMiddleware limits each IP to limit whoever it claims to be. It belongs
+// before auth, so failed credentials count against the caller too.
+func IPMiddleware(store Store, limit Limit) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		take(c, store, ipKey(c), limit)
+	}
+}
+
+// QuotaMiddleware limits each client, keyed like Middleware, to quota
+// requests per window. It belongs after Middleware, so requests the bucket
+// already refused do not use up the quota.
+func QuotaMiddleware(store QuotaStore, quota Quota) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		res, err := store.Spend(c.Request.Context(), clientKey(c), quota)
+		if err != nil {
+			logging.FromContext(c.Request.Context()).ErrorContext(c.Request.Context(), "quota store failed", "error", err)
+			c.Next()
+			return
+		}
+
+		c.Header("Quota-Limit", strconv.Itoa(res.Limit))
+		c.Header("Quota-Remaining", strconv.Itoa(res.Remaining))
+		c.Header("Quota-Reset", ceilSeconds(res.Reset))
+		if !res.Allowed {
+			c.Header("Retry-After", ceilSeconds(res.Reset))
+			problem.Abort(c, problem.New(http.StatusTooManyRequests, "quota_exceeded", "request quota used up, it resets in "+ceilSeconds(res.Reset)+"s"))
+			return
+		}
+		c.Next()
+	}
+}
+
+// take spends a token of key's bucket, answering 429 when there is none.
+func take(c *gin.Context, store Store, key string, limit Limit) {
+	res, err := store.Take(c.Request.Context(), key, limit)
+	if err != nil {
+		// fail open: a broken limiter must not take the API down
+		logging.FromContext(c.Request.Context()).ErrorContext(c.Request.Context(), "rate limit store failed", "error", err)
+		c.Next()
+		return
+	}
+
+	c.Header("RateLimit-Limit", strconv.Itoa(res.Limit))
+	c.Header("RateLimit-Remaining", strconv.Itoa(res.Remaining))
+	c.Header("RateLimit-Reset", ceilSeconds(res.Reset))
+	if !res.Allowed {
+		c.Header("Retry-After", ceilSeconds(res.RetryAfter))
+		problem.Abort(c, problem.New(http.StatusTooManyRequests, "rate_limited", "rate limit exceeded, retry after "+ceilSeconds(res.RetryAfter)+"s"))
+		return
+	}
+	c.Next()
+}
+
+func clientKey(c *gin.Context) string {
+	if p := auth.FromContext(c.Request.Context()); p != nil {
+		return "sub:" + p.Subject
+	}
+	return ipKey(c)
+}
+
+// ipKey keys a client by IP. ClientIP only believes X-Forwarded-For from
+// the engine's trusted proxies. IPv6 clients are keyed by their /64, which
+// a single host can hand out addresses from at will.
+func ipKey(c *gin.Context) string {
+	addr, err := netip.ParseAddr(c.ClientIP())
+	if err != nil {
+		return "ip:" + c.ClientIP()
+	}
+	addr = addr.Unmap()
+	if addr.Is6() {
+		prefix, _ := addr.Prefix(64)
+		return "ip:" + prefix.String()
+	}
+	return "ip:" + addr.String()
+}
+
+func ceilSeconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
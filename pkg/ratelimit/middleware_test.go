@@ -0,0 +1,146 @@
+package ratelimit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestIPKey(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tests := []struct {
+		name    string
+		trusted []string
+		remote  string
+		xff     string
+		want    string
+	}{
+		{"peer address", nil, "203.0.113.7:5000", "", "ip:203.0.113.7"},
+		{"forged header without trusted proxies", nil, "203.0.113.7:5000", "198.51.100.1", "ip:203.0.113.7"},
+		{"header from a trusted proxy", []string{"10.0.0.0/8"}, "10.1.2.3:5000", "198.51.100.1", "ip:198.51.100.1"},
+		{"header from an untrusted peer", []string{"10.0.0.0/8"}, "203.0.113.7:5000", "198.51.100.1", "ip:203.0.113.7"},
+		{"ipv6 by /64", nil, "[2001:db8:1:2:aaaa::1]:5000", "", "ip:2001:db8:1:2::/64"},
+		{"ipv4-mapped ipv6", nil, "[::ffff:203.0.113.7]:5000", "", "ip:203.0.113.7"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := gin.New()
+			if err := r.SetTrustedProxies(tt.trusted); err != nil {
+				t.Fatal(err)
+			}
+			var got string
+			r.GET("/", func(c *gin.Context) { got = ipKey(c) })
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.RemoteAddr = tt.remote
+			if tt.xff != "" {
+				req.Header.Set("X-Forwarded-For", tt.xff)
+			}
+			r.ServeHTTP(httptest.NewRecorder(), req)
+			if got != tt.want {
+				t.Errorf("ipKey = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIPMiddlewareCountsRejectedCredentials(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	if err := r.SetTrustedProxies(nil); err != nil {
+		t.Fatal(err)
+	}
+	r.Use(IPMiddleware(NewMemoryStore(), Limit{Rate: 0.001, Burst: 2}))
+	// stands in for auth rejecting every credential
+	r.Use(func(c *gin.Context) { c.AbortWithStatus(http.StatusUnauthorized) })
+	r.GET("/", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	want := []int{http.StatusUnauthorized, http.StatusUnauthorized, http.StatusTooManyRequests}
+	for i, status := range want {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.RemoteAddr = "203.0.113.7:5000"
+		// a fresh forged address on every attempt changes nothing
+		req.Header.Set("X-Forwarded-For", "198.51.100."+string(rune('1'+i)))
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != status {
+			t.Errorf("attempt %d: status = %d, want %d", i+1, w.Code, status)
+		}
+	}
+}
+
+func TestMiddlewareRouteOverride(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(Middleware(NewMemoryStore(), Limit{Rate: 0.001, Burst: 5}, map[string]Limit{
+		"POST /items/:id": {Rate: 0.001, Burst: 1},
+	}))
+	ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+	r.GET("/items/:id", ok)
+	r.POST("/items/:id", ok)
+
+	do := func(method, path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(method, path, nil))
+		return w
+	}
+	if w := do(http.MethodPost, "/items/1"); w.Code != http.StatusOK || w.Header().Get("RateLimit-Limit") != "1" {
+		t.Fatalf("first POST: status %d, RateLimit-Limit %q", w.Code, w.Header().Get("RateLimit-Limit"))
+	}
+	if w := do(http.MethodPost, "/items/2"); w.Code != http.StatusTooManyRequests || w.Header().Get("Retry-After") == "" {
+		t.Fatalf("second POST: status %d, Retry-After %q", w.Code, w.Header().Get("Retry-After"))
+	}
+	// the override has its own bucket, so the default one is untouched
+	if w := do(http.MethodGet, "/items/1"); w.Code != http.StatusOK || w.Header().Get("RateLimit-Remaining") != "4" {
+		t.Fatalf("GET: status %d, RateLimit-Remaining %q", w.Code, w.Header().Get("RateLimit-Remaining"))
+	}
+}
+
+func TestQuotaMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(Middleware(NewMemoryStore(), Limit{Rate: 0.001, Burst: 1}, nil))
+	r.Use(QuotaMiddleware(NewMemoryStore(), Quota{Requests: 2, Window: 24 * time.Hour}))
+	r.GET("/", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	do := func(remote string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.RemoteAddr = remote
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+	if w := do("203.0.113.7:5000"); w.Code != http.StatusOK || w.Header().Get("Quota-Remaining") != "1" {
+		t.Fatalf("first request: status %d, Quota-Remaining %q", w.Code, w.Header().Get("Quota-Remaining"))
+	}
+	// refused by the bucket, so the quota is not spent
+	if w := do("203.0.113.7:5000"); w.Code != http.StatusTooManyRequests || w.Header().Get("Quota-Remaining") != "" {
+		t.Fatalf("second request: status %d, Quota-Remaining %q", w.Code, w.Header().Get("Quota-Remaining"))
+	}
+	if w := do("198.51.100.1:5000"); w.Code != http.StatusOK || w.Header().Get("Quota-Remaining") != "1" {
+		t.Fatalf("other client: status %d, Quota-Remaining %q", w.Code, w.Header().Get("Quota-Remaining"))
+	}
+}
+
+func TestQuotaMiddlewareRefusesWhenUsedUp(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(QuotaMiddleware(NewMemoryStore(), Quota{Requests: 1, Window: 24 * time.Hour}))
+	r.GET("/", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	want := []int{http.StatusOK, http.StatusTooManyRequests}
+	for i, status := range want {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+		if w.Code != status {
+			t.Fatalf("request %d: status = %d, want %d", i+1, w.Code, status)
+		}
+		if status == http.StatusTooManyRequests && (w.Header().Get("Retry-After") == "" || !strings.Contains(w.Body.String(), "quota_exceeded")) {
+			t.Errorf("refusal: Retry-After %q, body %s", w.Header().Get("Retry-After"), w.Body)
+		}
+	}
+}
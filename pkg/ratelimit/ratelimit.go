@@ -0,0 +1,166 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// Limit is a token bucket: Rate tokens per second refill a bucket of at
+// most Burst tokens, and every request takes one.
+type Limit struct {
+	Rate  float64
+	Burst int
+}
+
+// Result is the outcome of taking a token.
+type Result struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	// Reset is the time until the bucket is full again.
+	Reset time.Duration
+	// RetryAfter is the time until the next token, zero when Allowed.
+	RetryAfter time.Duration
+}
+
+// Quota caps the requests of a client over a longer fixed window, such as
+// a day, on top of the bucket that shapes their rate.
+type Quota struct {
+	Requests int
+	Window   time.Duration
+}
+
+// QuotaResult is the outcome of spending one request of a quota.
+type QuotaResult struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	// Reset is the time until the window ends and the quota is whole again.
+	Reset time.Duration
+}
+
+// Store keeps buckets by key. The in-memory store is per process; a shared
+// backend such as Redis can implement the same interface.
+type Store interface {
+	Take(ctx context.Context, key string, limit Limit) (Result, error)
+}
+
+// QuotaStore counts requests per key and quota window. Like Store, a shared
+// backend can implement it.
+type QuotaStore interface {
+	Spend(ctx context.Context, key string, quota Quota) (QuotaResult, error)
+}
+
+type bucket struct {
+	limit  Limit
+	tokens float64
+	last   time.Time
+}
+
+func (b *bucket) refill(now time.Time) float64 {
+	return math.Min(float64(b.limit.Burst), b.tokens+now.Sub(b.last).Seconds()*b.limit.Rate)
+}
+
+// window counts the requests of one quota window.
+type window struct {
+	end  time.Time
+	used int
+}
+
+// MemoryStore is an in-process Store and QuotaStore.
+type MemoryStore struct {
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	windows   map[string]*window
+	now       func() time.Time
+	lastSweep time.Time
+}
+
+// sweepInterval is how often idle, full buckets are dropped.
+const sweepInterval = time.Minute
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		buckets: make(map[string]*bucket),
+		windows: make(map[string]*window),
+		now:     time.Now,
+	}
+}
+
+// Take implements Store.
+func (s *MemoryStore) Take(_ context.Context, key string, limit Limit) (Result, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	s.sweep(now)
+
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(limit.Burst), last: now}
+		s.buckets[key] = b
+	}
+	b.limit = limit
+	b.tokens = b.refill(now)
+	b.last = now
+
+	res := Result{Limit: limit.Burst}
+	if b.tokens >= 1 {
+		b.tokens--
+		res.Allowed = true
+	} else {
+		res.RetryAfter = seconds((1 - b.tokens) / limit.Rate)
+	}
+	res.Remaining = int(b.tokens)
+	res.Reset = seconds((float64(limit.Burst) - b.tokens) / limit.Rate)
+	return res, nil
+}
+
+// Spend implements QuotaStore. Windows are aligned to multiples of
+// quota.Window since the zero time, so a daily quota resets at midnight UTC.
+func (s *MemoryStore) Spend(_ context.Context, key string, quota Quota) (QuotaResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	s.sweep(now)
+
+	w, ok := s.windows[key]
+	if !ok || !now.Before(w.end) {
+		w = &window{end: now.Truncate(quota.Window).Add(quota.Window)}
+		s.windows[key] = w
+	}
+
+	res := QuotaResult{Limit: quota.Requests, Reset: w.end.Sub(now)}
+	if w.used < quota.Requests {
+		w.used++
+		res.Allowed = true
+	}
+	res.Remaining = quota.Requests - w.used
+	return res, nil
+}
+
+// sweep drops buckets that have refilled completely and quota windows that
+// have ended; recreating them later gives the same result.
+func (s *MemoryStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < sweepInterval {
+		return
+	}
+	s.lastSweep = now
+	for key, b := range s.buckets {
+		if b.refill(now) >= float64(b.limit.Burst) {
+			delete(s.buckets, key)
+		}
+	}
+	for key, w := range s.windows {
+		if !now.Before(w.end) {
+			delete(s.windows, key)
+		}
+	}
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
@@ -0,0 +1,138 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// clock is a settable time source for MemoryStore.
+type clock struct{ t time.Time }
+
+func (c *clock) now() time.Time { return c.t }
+
+func (c *clock) advance(d time.Duration) { c.t = c.t.Add(d) }
+
+func newTestStore() (*MemoryStore, *clock) {
+	c := &clock{t: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)}
+	s := NewMemoryStore()
+	s.now = c.now
+	return s, c
+}
+
+func TestMemoryStoreTake(t *testing.T) {
+	limit := Limit{Rate: 2, Burst: 3}
+	steps := []struct {
+		advance     time.Duration
+		allowed     bool
+		remaining   int
+		retryAfter  time.Duration
+		reset       time.Duration
+		description string
+	}{
+		{0, true, 2, 0, 500 * time.Millisecond, "a new bucket starts full"},
+		{0, true, 1, 0, time.Second, "second token"},
+		{0, true, 0, 0, 1500 * time.Millisecond, "last token"},
+		{0, false, 0, 500 * time.Millisecond, 1500 * time.Millisecond, "empty bucket"},
+		{250 * time.Millisecond, false, 0, 250 * time.Millisecond, 1250 * time.Millisecond, "half a token"},
+		{250 * time.Millisecond, true, 0, 0, 1500 * time.Millisecond, "refilled one token"},
+		{time.Hour, true, 2, 0, 500 * time.Millisecond, "refill stops at burst"},
+	}
+
+	s, c := newTestStore()
+	for _, step := range steps {
+		c.advance(step.advance)
+		res, err := s.Take(context.Background(), "k", limit)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := Result{Allowed: step.allowed, Limit: 3, Remaining: step.remaining, Reset: step.reset, RetryAfter: step.retryAfter}
+		if res != want {
+			t.Errorf("%s: Take() = %+v, want %+v", step.description, res, want)
+		}
+	}
+}
+
+func TestMemoryStoreKeysAreIndependent(t *testing.T) {
+	s, _ := newTestStore()
+	limit := Limit{Rate: 1, Burst: 1}
+	if res, _ := s.Take(context.Background(), "a", limit); !res.Allowed {
+		t.Fatal("first take of a denied")
+	}
+	if res, _ := s.Take(context.Background(), "a", limit); res.Allowed {
+		t.Fatal("second take of a allowed")
+	}
+	if res, _ := s.Take(context.Background(), "b", limit); !res.Allowed {
+		t.Fatal("b shares a's bucket")
+	}
+}
+
+func TestMemoryStoreSweep(t *testing.T) {
+	s, c := newTestStore()
+	ctx := context.Background()
+	slow := Limit{Rate: 0.001, Burst: 1}
+	fast := Limit{Rate: 100, Burst: 1}
+
+	s.Take(ctx, "slow", slow)
+	s.Take(ctx, "fast", fast)
+	if len(s.buckets) != 2 {
+		t.Fatalf("buckets = %d, want 2", len(s.buckets))
+	}
+
+	// refilled buckets survive until the next sweep
+	c.advance(sweepInterval / 2)
+	s.Take(ctx, "other", fast)
+	if len(s.buckets) != 3 {
+		t.Fatalf("buckets = %d before the sweep, want 3", len(s.buckets))
+	}
+
+	c.advance(sweepInterval)
+	s.Take(ctx, "new", fast)
+	if _, ok := s.buckets["fast"]; ok {
+		t.Error("full bucket fast was not swept")
+	}
+	if _, ok := s.buckets["other"]; ok {
+		t.Error("full bucket other was not swept")
+	}
+	if _, ok := s.buckets["slow"]; !ok {
+		t.Error("bucket slow, still refilling, was swept")
+	}
+	if _, ok := s.buckets["new"]; !ok {
+		t.Error("bucket new was not kept")
+	}
+}
+
+func TestMemoryStoreSpend(t *testing.T) {
+	quota := Quota{Requests: 2, Window: time.Hour}
+	steps := []struct {
+		advance     time.Duration
+		allowed     bool
+		remaining   int
+		reset       time.Duration
+		description string
+	}{
+		{0, true, 1, time.Hour, "a new window starts whole"},
+		{10 * time.Minute, true, 0, 50 * time.Minute, "last request"},
+		{10 * time.Minute, false, 0, 40 * time.Minute, "quota used up"},
+		{40 * time.Minute, true, 1, time.Hour, "the next window starts whole"},
+	}
+
+	s, c := newTestStore()
+	for _, step := range steps {
+		c.advance(step.advance)
+		res, err := s.Spend(context.Background(), "k", quota)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := QuotaResult{Allowed: step.allowed, Limit: 2, Remaining: step.remaining, Reset: step.reset}
+		if res != want {
+			t.Errorf("%s: Spend() = %+v, want %+v", step.description, res, want)
+		}
+	}
+
+	c.advance(time.Hour + sweepInterval)
+	s.Spend(context.Background(), "other", quota)
+	if _, ok := s.windows["k"]; ok {
+		t.Error("ended window k was not swept")
+	}
+}
@@ -0,0 +1,95 @@
+package repository
+
+import (
+	"context"
+	"customer-api/pkg/model"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type APIKeyRepository interface {
+	WithTx(tx *gorm.DB) APIKeyRepository
+	Create(ctx context.Context, key *model.APIKey) error
+	GetByID(ctx context.Context, id uuid.UUID) (*model.APIKey, error)
+	// GetForUpdate loads the key and locks its row until the transaction
+	// ends.
+	GetForUpdate(ctx context.Context, id uuid.UUID) (*model.APIKey, error)
+	GetByPrefix(ctx context.Context, prefix string) (*model.APIKey, error)
+	List(ctx context.Context) ([]model.APIKey, error)
+	Revoke(ctx context.Context, id uuid.UUID, at time.Time) error
+	TouchLastUsed(ctx context.Context, id uuid.UUID, at time.Time) error
+}
+
+type apiKeyRepository struct {
+	db *gorm.DB
+}
+
+// WithTx implements APIKeyRepository.
+func (r *apiKeyRepository) WithTx(tx *gorm.DB) APIKeyRepository {
+	return &apiKeyRepository{db: tx}
+}
+
+// Create implements APIKeyRepository.
+func (r *apiKeyRepository) Create(ctx context.Context, key *model.APIKey) error {
+	return r.db.WithContext(ctx).Create(key).Error
+}
+
+// GetByID implements APIKeyRepository.
+func (r *apiKeyRepository) GetByID(ctx context.Context, id uuid.UUID) (*model.APIKey, error) {
+	var key model.APIKey
+	if err := r.db.WithContext(ctx).First(&key, id).Error; err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+// GetForUpdate implements APIKeyRepository.
+func (r *apiKeyRepository) GetForUpdate(ctx context.Context, id uuid.UUID) (*model.APIKey, error) {
+	var key model.APIKey
+	err := r.db.WithContext(ctx).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		First(&key, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+// GetByPrefix implements APIKeyRepository.
+func (r *apiKeyRepository) GetByPrefix(ctx context.Context, prefix string) (*model.APIKey, error) {
+	var key model.APIKey
+	if err := r.db.WithContext(ctx).Where("prefix = ?", prefix).First(&key).Error; err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+// List implements APIKeyRepository.
+func (r *apiKeyRepository) List(ctx context.Context) ([]model.APIKey, error) {
+	list := []model.APIKey{}
+	if err := r.db.WithContext(ctx).Order("created_at desc").Find(&list).Error; err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// Revoke implements APIKeyRepository.
+func (r *apiKeyRepository) Revoke(ctx context.Context, id uuid.UUID, at time.Time) error {
+	return r.db.WithContext(ctx).Model(&model.APIKey{}).
+		Where("id = ? AND revoked_at IS NULL", id).
+		Update("revoked_at", at).Error
+}
+
+// TouchLastUsed implements APIKeyRepository.
+func (r *apiKeyRepository) TouchLastUsed(ctx context.Context, id uuid.UUID, at time.Time) error {
+	return r.db.WithContext(ctx).Model(&model.APIKey{}).
+		Where("id = ?", id).
+		UpdateColumn("last_used_at", at).Error
+}
+
+func NewAPIKeyRepository(db *gorm.DB) APIKeyRepository {
+	return &apiKeyRepository{db: db}
+}
@@ -0,0 +1,58 @@
+package repository
+
+import (
+	"context"
+	"customer-api/pkg/model"
+	"customer-api/pkg/pagination"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type AuditFilter struct {
+	EntityType string
+	EntityID   *uuid.UUID
+	Actor      string
+}
+
+type AuditRepository interface {
+	WithTx(tx *gorm.DB) AuditRepository
+	Add(ctx context.Context, entry *model.AuditLog) error
+	List(ctx context.Context, filter AuditFilter, p pagination.Params) (pagination.Page[model.AuditLog], error)
+}
+
+type auditRepository struct {
+	db *gorm.DB
+}
+
+// WithTx implements AuditRepository.
+func (r *auditRepository) WithTx(tx *gorm.DB) AuditRepository {
+	return &auditRepository{db: tx}
+}
+
+// Add implements AuditRepository.
+func (r *auditRepository) Add(ctx context.Context, entry *model.AuditLog) error {
+	return r.db.WithContext(ctx).Create(entry).Error
+}
+
+// List implements AuditRepository.
+func (r *auditRepository) List(ctx context.Context, filter AuditFilter, p pagination.Params) (pagination.Page[model.AuditLog], error) {
+	tx := r.db.WithContext(ctx).Model(&model.AuditLog{})
+	if filter.EntityType != "" {
+		tx = tx.Where("entity_type = ?", filter.EntityType)
+	}
+	if filter.EntityID != nil {
+		tx = tx.Where("entity_id = ?", *filter.EntityID)
+	}
+	if filter.Actor != "" {
+		tx = tx.Where("actor = ?", filter.Actor)
+	}
+
+	return paginate(tx, byCreatedAt, p, func(a model.AuditLog) (string, uuid.UUID) {
+		return timeKey(a.CreatedAt), a.ID
+	})
+}
+
+func NewAuditRepository(db *gorm.DB) AuditRepository {
+	return &auditRepository{db: db}
+}
@@ -1,65 +1,177 @@
 package repository
 
 import (
+	"context"
 	"customer-api/pkg/model"
+	"customer-api/pkg/pagination"
+	"strings"
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type CustomerRepository interface {
-	Create(cus *model.Customer) error
-	GetByID(id uuid.UUID) (*model.Customer, error)
-	Update(cus *model.Customer) error
-	Delete(id uuid.UUID) error
-	List(query string, limit, offset int) ([]model.Customer, error)
+	WithTx(tx *gorm.DB) CustomerRepository
+	Create(ctx context.Context, cus *model.Customer) error
+	GetByID(ctx context.Context, id uuid.UUID) (*model.Customer, error)
+	// GetForUpdate loads the customer and locks its row until the
+	// transaction ends, so concurrent writers of one customer take turns.
+	GetForUpdate(ctx context.Context, id uuid.UUID) (*model.Customer, error)
+	// IsErased reports whether the customer, deleted or not, has had their
+	// personal data erased.
+	IsErased(ctx context.Context, id uuid.UUID) (bool, error)
+	Update(ctx context.Context, cus *model.Customer) error
+	Delete(ctx context.Context, id uuid.UUID) error
+	List(ctx context.Context, query string, p pagination.Params) (pagination.Page[model.Customer], error)
+	// CreateBatch inserts customers with one statement, skipping those
+	// whose email is already taken, and returns the ones it inserted.
+	CreateBatch(ctx context.Context, cus []*model.Customer) ([]*model.Customer, error)
+	// FindByEmails returns the customers, deleted ones included, holding
+	// any of emails, and locks their rows until the transaction ends.
+	FindByEmails(ctx context.Context, emails []string) ([]model.Customer, error)
+	// Stream hands fn every customer matching query, in List order.
+	Stream(ctx context.Context, query string, fn func(*model.Customer) error) error
 }
 
 type customerRepository struct {
 	db *gorm.DB
 }
 
+// WithTx implements CustomerRepository.
+func (r *customerRepository) WithTx(tx *gorm.DB) CustomerRepository {
+	return &customerRepository{db: tx}
+}
+
 // Create implements CustomerRepository.
-func (r *customerRepository) Create(cus *model.Customer) error {
-	return r.db.Create(cus).Error
+func (r *customerRepository) Create(ctx context.Context, cus *model.Customer) error {
+	return r.db.WithContext(ctx).Create(cus).Error
+}
+
+// CreateBatch implements CustomerRepository. Ids are assigned up front:
+// RETURNING leaves out the rows DO NOTHING skipped, so generated ids could
+// not be matched back to their customers.
+func (r *customerRepository) CreateBatch(ctx context.Context, cus []*model.Customer) ([]*model.Customer, error) {
+	if len(cus) == 0 {
+		return nil, nil
+	}
+	ids := make([]uuid.UUID, len(cus))
+	for i, c := range cus {
+		if c.ID == uuid.Nil {
+			c.ID = uuid.New()
+		}
+		ids[i] = c.ID
+	}
+
+	db := r.db.WithContext(ctx)
+	err := db.Clauses(clause.OnConflict{Columns: []clause.Column{{Name: "email"}}, DoNothing: true}).Create(cus).Error
+	if err != nil {
+		return nil, err
+	}
+
+	// RowsAffected counts the skipped rows too, so ask which ids landed
+	var stored []uuid.UUID
+	if err := db.Model(&model.Customer{}).Where("id IN ?", ids).Pluck("id", &stored).Error; err != nil {
+		return nil, err
+	}
+	kept := make(map[uuid.UUID]bool, len(stored))
+	for _, id := range stored {
+		kept[id] = true
+	}
+	inserted := make([]*model.Customer, 0, len(stored))
+	for _, c := range cus {
+		if kept[c.ID] {
+			inserted = append(inserted, c)
+		}
+	}
+	return inserted, nil
 }
 
 // Delete implements CustomerRepository.
-func (r *customerRepository) Delete(id uuid.UUID) error {
-	return r.db.Delete(&model.Customer{}, id).Error
+func (r *customerRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	return r.db.WithContext(ctx).Delete(&model.Customer{}, id).Error
+}
+
+// FindByEmails implements CustomerRepository.
+func (r *customerRepository) FindByEmails(ctx context.Context, emails []string) ([]model.Customer, error) {
+	var list []model.Customer
+	if len(emails) == 0 {
+		return list, nil
+	}
+	// deleted customers keep their email in the unique index
+	err := r.db.WithContext(ctx).Unscoped().
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("email IN ?", emails).
+		Find(&list).Error
+	return list, err
 }
 
 // GetByID implements CustomerRepository.
-func (r *customerRepository) GetByID(id uuid.UUID) (*model.Customer, error) {
+func (r *customerRepository) GetByID(ctx context.Context, id uuid.UUID) (*model.Customer, error) {
 	var c model.Customer
-	if err := r.db.First(&c, id).Error; err != nil {
+	if err := r.db.WithContext(ctx).First(&c, id).Error; err != nil {
 		return nil, err
 	}
 	return &c, nil
 }
 
-// List implements CustomerRepository.
-func (r *customerRepository) List(query string, limit int, offset int) ([]model.Customer, error) {
-	var list []model.Customer
-	result := r.db.
-		Order("name asc").
-		Limit(limit).
-		Offset(offset).
-		Preload("Feedbacks").
-		Preload("Interactions").
-		Find(&list)
-
-	if result.Error != nil {
-		return nil, result.Error
+// GetForUpdate implements CustomerRepository.
+func (r *customerRepository) GetForUpdate(ctx context.Context, id uuid.UUID) (*model.Customer, error) {
+	var c model.Customer
+	err := r.db.WithContext(ctx).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		First(&c, id).Error
+	if err != nil {
+		return nil, err
 	}
-	return list, nil
+	return &c, nil
+}
+
+// IsErased implements CustomerRepository.
+func (r *customerRepository) IsErased(ctx context.Context, id uuid.UUID) (bool, error) {
+	var erased bool
+	err := r.db.WithContext(ctx).Unscoped().Model(&model.Customer{}).
+		Select("erased_at IS NOT NULL").
+		Where("id = ?", id).
+		Scan(&erased).Error
+	return erased, err
+}
+
+// List implements CustomerRepository.
+func (r *customerRepository) List(ctx context.Context, query string, p pagination.Params) (pagination.Page[model.Customer], error) {
+	return paginate(r.search(ctx, query), byName, p, customerKey, func(db *gorm.DB) *gorm.DB {
+		return db.Preload("Feedbacks").Preload("Interactions")
+	})
+}
+
+// Stream implements CustomerRepository.
+func (r *customerRepository) Stream(ctx context.Context, query string, fn func(*model.Customer) error) error {
+	return stream(r.search(ctx, query), byName, customerKey, fn)
 }
 
 // Update implements CustomerRepository.
-func (r *customerRepository) Update(cus *model.Customer) error {
-	return r.db.Save(cus).Error
+func (r *customerRepository) Update(ctx context.Context, cus *model.Customer) error {
+	return r.db.WithContext(ctx).Save(cus).Error
 }
 
 func NewRepository(db *gorm.DB) CustomerRepository {
 	return &customerRepository{db: db}
 }
+
+// search matches query against name, email and phone; empty matches all.
+func (r *customerRepository) search(ctx context.Context, query string) *gorm.DB {
+	tx := r.db.WithContext(ctx).Model(&model.Customer{})
+	if query != "" {
+		// ILIKE '%...%' is served by the pg_trgm GIN indexes on name, email and phone
+		pattern := "%" + escapeLike(query) + "%"
+		tx = tx.Where("name ILIKE ? OR email ILIKE ? OR phone ILIKE ?", pattern, pattern, pattern)
+	}
+	return tx
+}
+
+// escapeLike escapes the LIKE wildcards in s so user input is matched literally.
+func escapeLike(s string) string {
+	return likeEscaper.Replace(s)
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
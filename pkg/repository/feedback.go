@@ -1,59 +1,89 @@
 package repository
 
 import (
+	"context"
 	"customer-api/pkg/model"
+	"customer-api/pkg/pagination"
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+type FeedbackFilter struct {
+	CustomerID *uuid.UUID
+	ProductID  *uuid.UUID
+}
+
 type FeedbackRepository interface {
-	Create(fd *model.Feedback) error
-	GetByID(id uuid.UUID) (*model.Feedback, error)
-	Update(cus *model.Feedback) error
-	Delete(id uuid.UUID) error
-	List(query string, limit, offset int) ([]model.Feedback, error)
+	WithTx(tx *gorm.DB) FeedbackRepository
+	Create(ctx context.Context, fd *model.Feedback) error
+	GetByID(ctx context.Context, id uuid.UUID) (*model.Feedback, error)
+	// GetForUpdate loads the feedback and locks its row until the
+	// transaction ends.
+	GetForUpdate(ctx context.Context, id uuid.UUID) (*model.Feedback, error)
+	Update(ctx context.Context, cus *model.Feedback) error
+	Delete(ctx context.Context, id uuid.UUID) error
+	List(ctx context.Context, filter FeedbackFilter, p pagination.Params) (pagination.Page[model.Feedback], error)
+	// Stream hands fn all feedback matching filter, in List order.
+	Stream(ctx context.Context, filter FeedbackFilter, fn func(*model.Feedback) error) error
 }
 
 type feedbackRepository struct {
 	db *gorm.DB
 }
 
+// WithTx implements FeedbackRepository.
+func (f *feedbackRepository) WithTx(tx *gorm.DB) FeedbackRepository {
+	return &feedbackRepository{db: tx}
+}
+
 // Create implements FeedbackRepository.
-func (f *feedbackRepository) Create(fd *model.Feedback) error {
-	return f.db.Create(fd).Error
+func (f *feedbackRepository) Create(ctx context.Context, fd *model.Feedback) error {
+	return f.db.WithContext(ctx).Create(fd).Error
 }
 
 // Delete implements FeedbackRepository.
-func (f *feedbackRepository) Delete(id uuid.UUID) error {
-	return f.db.Delete(id).Error
+func (f *feedbackRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	return f.db.WithContext(ctx).Delete(&model.Feedback{}, id).Error
 }
 
 // GetByID implements FeedbackRepository.
-func (f *feedbackRepository) GetByID(id uuid.UUID) (*model.Feedback, error) {
+func (f *feedbackRepository) GetByID(ctx context.Context, id uuid.UUID) (*model.Feedback, error) {
 	var feedback model.Feedback
 
-	if err := f.db.First(&feedback, id).Error; err != nil {
+	if err := f.db.WithContext(ctx).First(&feedback, id).Error; err != nil {
 		return nil, err
 	}
 
 	return &feedback, nil
 }
 
+// GetForUpdate implements FeedbackRepository.
+func (f *feedbackRepository) GetForUpdate(ctx context.Context, id uuid.UUID) (*model.Feedback, error) {
+	var feedback model.Feedback
+	err := f.db.WithContext(ctx).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		First(&feedback, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &feedback, nil
+}
+
 // List implements FeedbackRepository.
-func (f *feedbackRepository) List(query string, limit int, offset int) ([]model.Feedback, error) {
-	var list []model.Feedback
+func (f *feedbackRepository) List(ctx context.Context, filter FeedbackFilter, p pagination.Params) (pagination.Page[model.Feedback], error) {
+	return paginate(f.filter(ctx, filter), byCreatedAt, p, feedbackKey)
+}
 
-	result := f.db.Find(&list)
-	if result.Error != nil {
-		return nil, result.Error
-	}
-	return list, nil
+// Stream implements FeedbackRepository.
+func (f *feedbackRepository) Stream(ctx context.Context, filter FeedbackFilter, fn func(*model.Feedback) error) error {
+	return stream(f.filter(ctx, filter), byCreatedAt, feedbackKey, fn)
 }
 
 // Update implements FeedbackRepository.
-func (f *feedbackRepository) Update(fd *model.Feedback) error {
-	return f.db.Save(fd).Error
+func (f *feedbackRepository) Update(ctx context.Context, fd *model.Feedback) error {
+	return f.db.WithContext(ctx).Save(fd).Error
 }
 
 func NewFeedbackRepository(db *gorm.DB) FeedbackRepository {
@@ -61,3 +91,14 @@ func NewFeedbackRepository(db *gorm.DB) FeedbackRepository {
 		db: db,
 	}
 }
+
+func (f *feedbackRepository) filter(ctx context.Context, filter FeedbackFilter) *gorm.DB {
+	tx := f.db.WithContext(ctx).Model(&model.Feedback{})
+	if filter.CustomerID != nil {
+		tx = tx.Where("customer_id = ?", *filter.CustomerID)
+	}
+	if filter.ProductID != nil {
+		tx = tx.Where("product_id = ?", *filter.ProductID)
+	}
+	return tx
+}
@@ -0,0 +1,109 @@
+package repository
+
+import (
+	"context"
+	"customer-api/pkg/model"
+	"customer-api/pkg/pagination"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type InteractionFilter struct {
+	CustomerID *uuid.UUID
+	Channel    string
+	From       *time.Time
+	To         *time.Time
+}
+
+type InteractionRepository interface {
+	WithTx(tx *gorm.DB) InteractionRepository
+	Create(ctx context.Context, in *model.Interaction) error
+	GetByID(ctx context.Context, id uuid.UUID) (*model.Interaction, error)
+	// GetForUpdate loads the interaction and locks its row until the
+	// transaction ends.
+	GetForUpdate(ctx context.Context, id uuid.UUID) (*model.Interaction, error)
+	Update(ctx context.Context, in *model.Interaction) error
+	Delete(ctx context.Context, id uuid.UUID) error
+	List(ctx context.Context, filter InteractionFilter, p pagination.Params) (pagination.Page[model.Interaction], error)
+	// Stream hands fn every interaction matching filter, in List order.
+	Stream(ctx context.Context, filter InteractionFilter, fn func(*model.Interaction) error) error
+}
+
+type interactionRepository struct {
+	db *gorm.DB
+}
+
+// WithTx implements InteractionRepository.
+func (r *interactionRepository) WithTx(tx *gorm.DB) InteractionRepository {
+	return &interactionRepository{db: tx}
+}
+
+// Create implements InteractionRepository.
+func (r *interactionRepository) Create(ctx context.Context, in *model.Interaction) error {
+	return r.db.WithContext(ctx).Create(in).Error
+}
+
+// Delete implements InteractionRepository.
+func (r *interactionRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	return r.db.WithContext(ctx).Delete(&model.Interaction{}, id).Error
+}
+
+// GetByID implements InteractionRepository.
+func (r *interactionRepository) GetByID(ctx context.Context, id uuid.UUID) (*model.Interaction, error) {
+	var in model.Interaction
+	if err := r.db.WithContext(ctx).First(&in, id).Error; err != nil {
+		return nil, err
+	}
+	return &in, nil
+}
+
+// GetForUpdate implements InteractionRepository.
+func (r *interactionRepository) GetForUpdate(ctx context.Context, id uuid.UUID) (*model.Interaction, error) {
+	var in model.Interaction
+	err := r.db.WithContext(ctx).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		First(&in, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &in, nil
+}
+
+// List implements InteractionRepository.
+func (r *interactionRepository) List(ctx context.Context, filter InteractionFilter, p pagination.Params) (pagination.Page[model.Interaction], error) {
+	return paginate(r.filter(ctx, filter), byCreatedAt, p, interactionKey)
+}
+
+// Stream implements InteractionRepository.
+func (r *interactionRepository) Stream(ctx context.Context, filter InteractionFilter, fn func(*model.Interaction) error) error {
+	return stream(r.filter(ctx, filter), byCreatedAt, interactionKey, fn)
+}
+
+// Update implements InteractionRepository.
+func (r *interactionRepository) Update(ctx context.Context, in *model.Interaction) error {
+	return r.db.WithContext(ctx).Save(in).Error
+}
+
+func NewInteractionRepository(db *gorm.DB) InteractionRepository {
+	return &interactionRepository{db: db}
+}
+
+func (r *interactionRepository) filter(ctx context.Context, filter InteractionFilter) *gorm.DB {
+	tx := r.db.WithContext(ctx).Model(&model.Interaction{})
+	if filter.CustomerID != nil {
+		tx = tx.Where("customer_id = ?", *filter.CustomerID)
+	}
+	if filter.Channel != "" {
+		tx = tx.Where("channel = ?", filter.Channel)
+	}
+	if filter.From != nil {
+		tx = tx.Where("created_at >= ?", *filter.From)
+	}
+	if filter.To != nil {
+		tx = tx.Where("created_at < ?", *filter.To)
+	}
+	return tx
+}
@@ -0,0 +1,161 @@
+package repository
+
+import (
+	"context"
+	"customer-api/pkg/event"
+	"customer-api/pkg/model"
+	"encoding/json"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// outboxLockKey is the advisory lock held while claiming, so two relays
+// never claim at the same time.
+const outboxLockKey = 7_301_000_001
+
+type OutboxRepository interface {
+	WithTx(tx *gorm.DB) OutboxRepository
+	Add(ctx context.Context, events ...event.Event) error
+	// Claim hands the caller up to limit unsent messages, oldest first, for
+	// lease, and commits. It returns none while another claim is live, so
+	// batches go out one at a time and a failed batch holds back the rest.
+	// A claim that lapses, say because its relay died, is handed out again.
+	// Parked messages are skipped.
+	Claim(ctx context.Context, limit int, lease time.Duration) ([]model.OutboxMessage, error)
+	// MarkSent records claimed messages as published.
+	MarkSent(ctx context.Context, ids []int64) error
+	// Release ends the claim on messages that failed to publish, recording
+	// the attempt and cause, so the next Claim retries them.
+	Release(ctx context.Context, ids []int64, cause error) error
+	// Unclaim ends the claim on messages that were not tried, without
+	// counting an attempt.
+	Unclaim(ctx context.Context, ids []int64) error
+	// Park ends the claim on a message that failed for the last time,
+	// recording the attempt and cause, and sets it aside for good.
+	Park(ctx context.Context, id int64, cause error) error
+}
+
+type outboxRepository struct {
+	db *gorm.DB
+}
+
+// WithTx implements OutboxRepository.
+func (r *outboxRepository) WithTx(tx *gorm.DB) OutboxRepository {
+	return &outboxRepository{db: tx}
+}
+
+// Add implements OutboxRepository.
+func (r *outboxRepository) Add(ctx context.Context, events ...event.Event) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	msgs := make([]model.OutboxMessage, 0, len(events))
+	for _, e := range events {
+		payload, err := json.Marshal(e)
+		if err != nil {
+			return err
+		}
+		msgs = append(msgs, model.OutboxMessage{
+			EventID: e.ID,
+			Type:    e.Type,
+			Topic:   e.Topic(),
+			Key:     e.AggregateID.String(),
+			Payload: string(payload),
+		})
+	}
+	return r.db.WithContext(ctx).Create(&msgs).Error
+}
+
+// Claim implements OutboxRepository. Events of one entity are inserted in
+// commit order, as writers hold the entity's row lock, so id order keeps
+// their order even though ids of concurrent transactions may commit out of
+// sequence.
+func (r *outboxRepository) Claim(ctx context.Context, limit int, lease time.Duration) ([]model.OutboxMessage, error) {
+	var msgs []model.OutboxMessage
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var locked bool
+		if err := tx.Raw("SELECT pg_try_advisory_xact_lock(?)", outboxLockKey).Scan(&locked).Error; err != nil {
+			return err
+		}
+		if !locked {
+			return nil
+		}
+
+		var live int64
+		err := tx.Model(&model.OutboxMessage{}).
+			Where("sent_at IS NULL AND failed_at IS NULL AND claimed_until > now()").
+			Count(&live).Error
+		if err != nil || live > 0 {
+			return err
+		}
+
+		err = tx.
+			Where("sent_at IS NULL AND failed_at IS NULL").
+			Order("id asc").
+			Limit(limit).
+			Find(&msgs).Error
+		if err != nil || len(msgs) == 0 {
+			return err
+		}
+		return tx.Model(&model.OutboxMessage{}).
+			Where("id IN ?", outboxIDs(msgs)).
+			Update("claimed_until", gorm.Expr("now() + make_interval(secs => ?)", lease.Seconds())).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return msgs, nil
+}
+
+// MarkSent implements OutboxRepository.
+func (r *outboxRepository) MarkSent(ctx context.Context, ids []int64) error {
+	return r.update(ctx, ids, map[string]any{
+		"sent_at":       time.Now(),
+		"claimed_until": nil,
+	})
+}
+
+// Release implements OutboxRepository.
+func (r *outboxRepository) Release(ctx context.Context, ids []int64, cause error) error {
+	return r.update(ctx, ids, map[string]any{
+		"attempts":      gorm.Expr("attempts + 1"),
+		"last_error":    cause.Error(),
+		"claimed_until": nil,
+	})
+}
+
+// Unclaim implements OutboxRepository.
+func (r *outboxRepository) Unclaim(ctx context.Context, ids []int64) error {
+	return r.update(ctx, ids, map[string]any{"claimed_until": nil})
+}
+
+// Park implements OutboxRepository.
+func (r *outboxRepository) Park(ctx context.Context, id int64, cause error) error {
+	return r.update(ctx, []int64{id}, map[string]any{
+		"attempts":      gorm.Expr("attempts + 1"),
+		"last_error":    cause.Error(),
+		"claimed_until": nil,
+		"failed_at":     time.Now(),
+	})
+}
+
+func (r *outboxRepository) update(ctx context.Context, ids []int64, values map[string]any) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	return r.db.WithContext(ctx).Model(&model.OutboxMessage{}).Where("id IN ?", ids).Updates(values).Error
+}
+
+func outboxIDs(msgs []model.OutboxMessage) []int64 {
+	ids := make([]int64, len(msgs))
+	for i, m := range msgs {
+		ids[i] = m.ID
+	}
+	return ids
+}
+
+func NewOutboxRepository(db *gorm.DB) OutboxRepository {
+	return &outboxRepository{db: db}
+}
@@ -0,0 +1,139 @@
+package repository
+
+import (
+	"customer-api/pkg/model"
+	"customer-api/pkg/pagination"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// keyset describes the ordering a list is paged over: column then id.
+type keyset struct {
+	column string
+	desc   bool
+	isTime bool
+}
+
+var (
+	byName      = keyset{column: "name"}
+	byCreatedAt = keyset{column: "created_at", desc: true, isTime: true}
+)
+
+// paginate counts the rows matched by tx and loads the page after p.Cursor.
+// key returns the sort column value and id of an item, used to build the
+// next cursor. scopes only apply to the page query, not the count.
+func paginate[T any](tx *gorm.DB, ks keyset, p pagination.Params, key func(T) (string, uuid.UUID), scopes ...func(*gorm.DB) *gorm.DB) (pagination.Page[T], error) {
+	page := pagination.Page[T]{Items: []T{}}
+	p = p.WithDefaults()
+
+	base := tx.Session(&gorm.Session{})
+	if err := base.Count(&page.Total).Error; err != nil {
+		return page, err
+	}
+
+	q := base.Scopes(scopes...)
+	if p.Cursor != "" {
+		cur, err := pagination.Decode(p.Cursor)
+		if err != nil {
+			return page, err
+		}
+		if q, err = ks.after(q, *cur); err != nil {
+			return page, err
+		}
+	}
+
+	var items []T
+	err := q.
+		Order(ks.order()).
+		Limit(p.Limit + 1).
+		Find(&items).Error
+	if err != nil {
+		return page, err
+	}
+
+	if len(items) > p.Limit {
+		items = items[:p.Limit]
+		k, id := key(items[len(items)-1])
+		page.NextCursor = pagination.Cursor{Key: k, ID: id}.Encode()
+	}
+	page.Items = append(page.Items, items...)
+	return page, nil
+}
+
+// streamBatch is how many rows stream loads per query.
+const streamBatch = 1000
+
+// stream runs tx's query in ks order and hands each row to fn. Rows are
+// loaded streamBatch at a time, each batch picking up after the last row
+// of the previous one like a page cursor, so memory stays flat and no
+// connection is held while fn writes. The batches are separate snapshots:
+// a row changed during the stream can be missed or seen twice if its sort
+// column moves.
+func stream[T any](tx *gorm.DB, ks keyset, key func(T) (string, uuid.UUID), fn func(*T) error) error {
+	base := tx.Session(&gorm.Session{})
+	q := base
+	for {
+		var items []T
+		if err := q.Order(ks.order()).Limit(streamBatch).Find(&items).Error; err != nil {
+			return err
+		}
+		for i := range items {
+			if err := fn(&items[i]); err != nil {
+				return err
+			}
+		}
+		if len(items) < streamBatch {
+			return nil
+		}
+
+		k, id := key(items[len(items)-1])
+		var err error
+		if q, err = ks.after(base, pagination.Cursor{Key: k, ID: id}); err != nil {
+			return err
+		}
+	}
+}
+
+// after narrows q to the rows that come after cur in ks order.
+func (ks keyset) after(q *gorm.DB, cur pagination.Cursor) (*gorm.DB, error) {
+	var k any = cur.Key
+	if ks.isTime {
+		t, err := time.Parse(time.RFC3339Nano, cur.Key)
+		if err != nil {
+			return nil, pagination.ErrInvalidCursor
+		}
+		k = t
+	}
+	op := ">"
+	if ks.desc {
+		op = "<"
+	}
+	return q.Where(fmt.Sprintf("(%s, id) %s (?, ?)", ks.column, op), k, cur.ID), nil
+}
+
+func (ks keyset) order() string {
+	dir := "asc"
+	if ks.desc {
+		dir = "desc"
+	}
+	return fmt.Sprintf("%s %s, id %s", ks.column, dir, dir)
+}
+
+func customerKey(c model.Customer) (string, uuid.UUID) {
+	return c.Name, c.ID
+}
+
+func feedbackKey(fd model.Feedback) (string, uuid.UUID) {
+	return timeKey(fd.CreatedAt), fd.ID
+}
+
+func interactionKey(in model.Interaction) (string, uuid.UUID) {
+	return timeKey(in.CreatedAt), in.ID
+}
+
+func timeKey(t time.Time) string {
+	return t.UTC().Format(time.RFC3339Nano)
+}
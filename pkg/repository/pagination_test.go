@@ -0,0 +1,87 @@
+package repository
+
+import (
+	"customer-api/pkg/model"
+	"customer-api/pkg/pagination"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// dryRun returns a database that builds statements without running them.
+func dryRun(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=localhost"}), &gorm.Config{
+		DryRun:                 true,
+		DisableAutomaticPing:   true,
+		SkipDefaultTransaction: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func TestPaginateRejectsTamperedKey(t *testing.T) {
+	db := dryRun(t)
+	key := func(c model.Customer) (string, uuid.UUID) { return timeKey(c.CreatedAt), c.ID }
+
+	tests := []struct {
+		name   string
+		cursor string
+	}{
+		{"garbled", "not-a-cursor"},
+		{"name where a time is expected", pagination.Cursor{Key: "Alice", ID: uuid.New()}.Encode()},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := paginate(db.Model(&model.Customer{}), byCreatedAt, pagination.Params{Cursor: tt.cursor}, key)
+			if !errors.Is(err, pagination.ErrInvalidCursor) {
+				t.Errorf("error = %v, want ErrInvalidCursor", err)
+			}
+		})
+	}
+}
+
+func TestKeysetOrder(t *testing.T) {
+	if got, want := byName.order(), "name asc, id asc"; got != want {
+		t.Errorf("byName.order() = %q, want %q", got, want)
+	}
+	if got, want := byCreatedAt.order(), "created_at desc, id desc"; got != want {
+		t.Errorf("byCreatedAt.order() = %q, want %q", got, want)
+	}
+}
+
+func TestKeysetAfter(t *testing.T) {
+	db := dryRun(t)
+	id := uuid.MustParse("9d3c8a52-0a47-4d9e-9b0b-6f1f6f4c2a10")
+
+	tests := []struct {
+		name string
+		ks   keyset
+		key  string
+		want string
+	}{
+		{"ascending", byName, "Alice",
+			`SELECT * FROM "customers" WHERE (name, id) > ('Alice', '` + id.String() + `') AND "customers"."deleted_at" IS NULL`},
+		{"descending time", byCreatedAt, "2026-01-02T03:04:05.123456Z",
+			`SELECT * FROM "customers" WHERE (created_at, id) < ('2026-01-02 03:04:05.123', '` + id.String() + `') AND "customers"."deleted_at" IS NULL`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := db.ToSQL(func(tx *gorm.DB) *gorm.DB {
+				q, err := tt.ks.after(tx.Model(&model.Customer{}), pagination.Cursor{Key: tt.key, ID: id})
+				if err != nil {
+					t.Fatal(err)
+				}
+				return q.Find(&[]model.Customer{})
+			})
+			if got != tt.want {
+				t.Errorf("SQL = %s\nwant  %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,134 @@
+package repository
+
+import (
+	"context"
+	"customer-api/pkg/model"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// PersonalFields are the JSON keys of personal data in audit snapshots and
+// event payloads: a customer's contact details and the free text written
+// about them. Migration 0009 repeats them in erase_audit_personal_data.
+var PersonalFields = []string{"name", "email", "phone", "comment", "description"}
+
+// personalFieldsArray is PersonalFields as a Postgres text[] literal, for
+// the jsonb - text[] operator.
+var personalFieldsArray = "{" + strings.Join(PersonalFields, ",") + "}"
+
+// SubjectData is everything stored about one customer, deleted rows
+// included.
+type SubjectData struct {
+	Customer     model.Customer
+	Feedbacks    []model.Feedback
+	Interactions []model.Interaction
+}
+
+type PrivacyRepository interface {
+	WithTx(tx *gorm.DB) PrivacyRepository
+	// Collect loads the customer and all their feedback and interactions.
+	Collect(ctx context.Context, customerID uuid.UUID) (*SubjectData, error)
+	// Erase anonymises the customer locked in the current transaction: it
+	// overwrites their contact details, blanks the text of their feedback
+	// and interactions and strips PersonalFields from the audit log and
+	// outbox entries about them. Ratings, products, channels and
+	// timestamps are kept. It must run inside a transaction.
+	Erase(ctx context.Context, customerID uuid.UUID, at time.Time) error
+	// LockCustomer loads the customer, deleted or not, for update.
+	LockCustomer(ctx context.Context, customerID uuid.UUID) (*model.Customer, error)
+}
+
+type privacyRepository struct {
+	db *gorm.DB
+}
+
+// WithTx implements PrivacyRepository.
+func (r *privacyRepository) WithTx(tx *gorm.DB) PrivacyRepository {
+	return &privacyRepository{db: tx}
+}
+
+// Collect implements PrivacyRepository.
+func (r *privacyRepository) Collect(ctx context.Context, customerID uuid.UUID) (*SubjectData, error) {
+	db := r.db.WithContext(ctx).Unscoped()
+
+	data := &SubjectData{Feedbacks: []model.Feedback{}, Interactions: []model.Interaction{}}
+	if err := db.First(&data.Customer, customerID).Error; err != nil {
+		return nil, err
+	}
+	if err := db.Where("customer_id = ?", customerID).Order("created_at, id").Find(&data.Feedbacks).Error; err != nil {
+		return nil, err
+	}
+	if err := db.Where("customer_id = ?", customerID).Order("created_at, id").Find(&data.Interactions).Error; err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// LockCustomer implements PrivacyRepository.
+func (r *privacyRepository) LockCustomer(ctx context.Context, customerID uuid.UUID) (*model.Customer, error) {
+	var c model.Customer
+	err := r.db.WithContext(ctx).Unscoped().
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		First(&c, customerID).Error
+	if err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// Erase implements PrivacyRepository.
+func (r *privacyRepository) Erase(ctx context.Context, customerID uuid.UUID, at time.Time) error {
+	db := r.db.WithContext(ctx)
+
+	var feedbackIDs []uuid.UUID
+	err := db.Unscoped().Model(&model.Feedback{}).Where("customer_id = ?", customerID).Pluck("id", &feedbackIDs).Error
+	if err != nil {
+		return err
+	}
+
+	// the email stays unique and frees the real address for a new sign-up
+	err = db.Unscoped().Model(&model.Customer{}).Where("id = ?", customerID).Updates(map[string]any{
+		"name":       "Erased customer",
+		"email":      fmt.Sprintf("erased+%s@invalid", customerID),
+		"phone":      "",
+		"erased_at":  at,
+		"updated_at": at,
+		"deleted_at": gorm.Expr("COALESCE(deleted_at, ?)", at),
+	}).Error
+	if err != nil {
+		return err
+	}
+	err = db.Unscoped().Model(&model.Feedback{}).Where("customer_id = ?", customerID).
+		Update("comment", "").Error
+	if err != nil {
+		return err
+	}
+	err = db.Unscoped().Model(&model.Interaction{}).Where("customer_id = ?", customerID).
+		Update("description", "").Error
+	if err != nil {
+		return err
+	}
+
+	// only the erase_audit_personal_data function, owned by the erasure
+	// role, gets past the audit trigger
+	if err := db.Exec("SELECT erase_audit_personal_data(?)", customerID).Error; err != nil {
+		return err
+	}
+
+	keys := []string{customerID.String()}
+	for _, id := range feedbackIDs {
+		keys = append(keys, id.String())
+	}
+	return db.Model(&model.OutboxMessage{}).
+		Where("key IN ? AND jsonb_typeof(payload->'data') = 'object'", keys).
+		Update("payload", gorm.Expr("jsonb_set(payload, '{data}', (payload->'data') - ?::text[])", personalFieldsArray)).Error
+}
+
+func NewPrivacyRepository(db *gorm.DB) PrivacyRepository {
+	return &privacyRepository{db: db}
+}
@@ -1,59 +1,87 @@
 package repository
 
 import (
+	"context"
 	"customer-api/pkg/model"
+	"customer-api/pkg/pagination"
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type ProductRepository interface {
-	Create(fd *model.Product) error
-	GetByID(id uuid.UUID) (*model.Product, error)
-	Update(cus *model.Product) error
-	Delete(id uuid.UUID) error
-	List(query string, limit, offset int) ([]model.Product, error)
+	WithTx(tx *gorm.DB) ProductRepository
+	Create(ctx context.Context, fd *model.Product) error
+	GetByID(ctx context.Context, id uuid.UUID) (*model.Product, error)
+	// GetForUpdate loads the product and locks its row until the
+	// transaction ends.
+	GetForUpdate(ctx context.Context, id uuid.UUID) (*model.Product, error)
+	Update(ctx context.Context, cus *model.Product) error
+	Delete(ctx context.Context, id uuid.UUID) error
+	List(ctx context.Context, query, category string, p pagination.Params) (pagination.Page[model.Product], error)
 }
 
 type productRepository struct {
 	db *gorm.DB
 }
 
+// WithTx implements ProductRepository.
+func (f *productRepository) WithTx(tx *gorm.DB) ProductRepository {
+	return &productRepository{db: tx}
+}
+
 // Create implements ProductRepository.
-func (f *productRepository) Create(fd *model.Product) error {
-	return f.db.Create(fd).Error
+func (f *productRepository) Create(ctx context.Context, fd *model.Product) error {
+	return f.db.WithContext(ctx).Create(fd).Error
 }
 
 // Delete implements ProductRepository.
-func (f *productRepository) Delete(id uuid.UUID) error {
-	return f.db.Delete(id).Error
+func (f *productRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	return f.db.WithContext(ctx).Delete(&model.Product{}, id).Error
 }
 
 // GetByID implements ProductRepository.
-func (f *productRepository) GetByID(id uuid.UUID) (*model.Product, error) {
+func (f *productRepository) GetByID(ctx context.Context, id uuid.UUID) (*model.Product, error) {
 	var Product model.Product
 
-	if err := f.db.First(&Product, id).Error; err != nil {
+	if err := f.db.WithContext(ctx).First(&Product, id).Error; err != nil {
 		return nil, err
 	}
 
 	return &Product, nil
 }
 
-// List implements ProductRepository.
-func (f *productRepository) List(query string, limit int, offset int) ([]model.Product, error) {
-	var list []model.Product
+// GetForUpdate implements ProductRepository.
+func (f *productRepository) GetForUpdate(ctx context.Context, id uuid.UUID) (*model.Product, error) {
+	var p model.Product
+	err := f.db.WithContext(ctx).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		First(&p, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
 
-	result := f.db.Find(&list)
-	if result.Error != nil {
-		return nil, result.Error
+// List implements ProductRepository.
+func (f *productRepository) List(ctx context.Context, query, category string, p pagination.Params) (pagination.Page[model.Product], error) {
+	tx := f.db.WithContext(ctx).Model(&model.Product{})
+	if query != "" {
+		tx = tx.Where("name ILIKE ?", "%"+escapeLike(query)+"%")
 	}
-	return list, nil
+	if category != "" {
+		tx = tx.Where("category = ?", category)
+	}
+
+	return paginate(tx, byName, p, func(pr model.Product) (string, uuid.UUID) {
+		return pr.Name, pr.ID
+	})
 }
 
 // Update implements ProductRepository.
-func (f *productRepository) Update(fd *model.Product) error {
-	return f.db.Save(fd).Error
+func (f *productRepository) Update(ctx context.Context, fd *model.Product) error {
+	return f.db.WithContext(ctx).Save(fd).Error
 }
 
 func NewProductRepository(db *gorm.DB) ProductRepository {
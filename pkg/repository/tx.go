@@ -0,0 +1,26 @@
+package repository
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+)
+
+// Transactor runs fn inside a database transaction. Repositories bound to
+// tx through their WithTx method take part in it.
+type Transactor interface {
+	Transaction(ctx context.Context, fn func(tx *gorm.DB) error) error
+}
+
+type transactor struct {
+	db *gorm.DB
+}
+
+// Transaction implements Transactor.
+func (t *transactor) Transaction(ctx context.Context, fn func(tx *gorm.DB) error) error {
+	return t.db.WithContext(ctx).Transaction(fn)
+}
+
+func NewTransactor(db *gorm.DB) Transactor {
+	return &transactor{db: db}
+}
@@ -0,0 +1,37 @@
+package requestid
+
+import (
+	"context"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// Header carries the request id in both directions.
+const Header = "X-Request-ID"
+
+type key struct{}
+
+// Middleware reuses the caller's X-Request-ID, or generates one, echoes it
+// on the response and stores it in the request context.
+func Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(Header)
+		if id == "" || len(id) > 128 {
+			id = uuid.NewString()
+		}
+		c.Header(Header, id)
+		c.Request = c.Request.WithContext(WithID(c.Request.Context(), id))
+		c.Next()
+	}
+}
+
+func WithID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, key{}, id)
+}
+
+// FromContext returns the request id in ctx, or "" outside a request.
+func FromContext(ctx context.Context) string {
+	id, _ := ctx.Value(key{}).(string)
+	return id
+}
@@ -0,0 +1,170 @@
+package service
+
+import (
+	"context"
+	"customer-api/pkg/auth"
+	"customer-api/pkg/model"
+	"customer-api/pkg/pagination"
+	"customer-api/pkg/repository"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type fakeAPIKeyRepository struct {
+	keys map[string]*model.APIKey
+	err  error
+}
+
+func (f *fakeAPIKeyRepository) WithTx(*gorm.DB) repository.APIKeyRepository               { return f }
+func (f *fakeAPIKeyRepository) List(context.Context) ([]model.APIKey, error)              { return nil, nil }
+func (f *fakeAPIKeyRepository) TouchLastUsed(context.Context, uuid.UUID, time.Time) error { return nil }
+
+func (f *fakeAPIKeyRepository) Create(_ context.Context, k *model.APIKey) error {
+	k.ID = uuid.New()
+	if f.keys == nil {
+		f.keys = map[string]*model.APIKey{}
+	}
+	f.keys[k.Prefix] = k
+	return nil
+}
+
+func (f *fakeAPIKeyRepository) GetByID(_ context.Context, id uuid.UUID) (*model.APIKey, error) {
+	for _, k := range f.keys {
+		if k.ID == id {
+			copied := *k
+			return &copied, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (f *fakeAPIKeyRepository) GetForUpdate(ctx context.Context, id uuid.UUID) (*model.APIKey, error) {
+	return f.GetByID(ctx, id)
+}
+
+func (f *fakeAPIKeyRepository) Revoke(_ context.Context, id uuid.UUID, at time.Time) error {
+	for _, k := range f.keys {
+		if k.ID == id && k.RevokedAt == nil {
+			k.RevokedAt = &at
+		}
+	}
+	return nil
+}
+
+func (f *fakeAPIKeyRepository) GetByPrefix(_ context.Context, prefix string) (*model.APIKey, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	k, ok := f.keys[prefix]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return k, nil
+}
+
+func TestAPIKeyAuthenticate(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	past, future := now.Add(-time.Hour), now.Add(time.Hour)
+
+	const key = auth.APIKeyPrefix + "0123abcd_secret"
+	stored := func(modify func(*model.APIKey)) map[string]*model.APIKey {
+		k := &model.APIKey{ID: uuid.New(), Prefix: "0123abcd", Hash: hashAPIKey(key), Scopes: []string{"customers:read"}}
+		modify(k)
+		return map[string]*model.APIKey{k.Prefix: k}
+	}
+	dbDown := errors.New("connection refused")
+
+	tests := []struct {
+		name string
+		key  string
+		repo *fakeAPIKeyRepository
+		want error
+	}{
+		{"valid", key, &fakeAPIKeyRepository{keys: stored(func(*model.APIKey) {})}, nil},
+		{"not expired yet", key, &fakeAPIKeyRepository{keys: stored(func(k *model.APIKey) { k.ExpiresAt = &future })}, nil},
+		{"malformed", "cak_short", &fakeAPIKeyRepository{}, ErrInvalidAPIKey},
+		{"unknown prefix", key, &fakeAPIKeyRepository{keys: map[string]*model.APIKey{}}, ErrInvalidAPIKey},
+		{"wrong secret", auth.APIKeyPrefix + "0123abcd_guess", &fakeAPIKeyRepository{keys: stored(func(*model.APIKey) {})}, ErrInvalidAPIKey},
+		{"revoked", key, &fakeAPIKeyRepository{keys: stored(func(k *model.APIKey) { k.RevokedAt = &past })}, ErrAPIKeyRevoked},
+		{"expired", key, &fakeAPIKeyRepository{keys: stored(func(k *model.APIKey) { k.ExpiresAt = &past })}, ErrAPIKeyExpired},
+		{"database error", key, &fakeAPIKeyRepository{err: dbDown}, dbDown},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &apiKeyService{repo: tt.repo, now: func() time.Time { return now }}
+			p, err := s.Authenticate(context.Background(), tt.key)
+			if !errors.Is(err, tt.want) || (tt.want == nil) != (err == nil) {
+				t.Fatalf("error = %v, want %v", err, tt.want)
+			}
+			if invalid := errors.Is(err, auth.ErrInvalidCredential); invalid != (tt.want != nil && tt.want != dbDown) {
+				t.Errorf("errors.Is(%v, ErrInvalidCredential) = %v", err, invalid)
+			}
+			if err == nil && (len(p.Scopes) != 1 || p.Scopes[0] != auth.CustomersRead) {
+				t.Errorf("principal = %+v", p)
+			}
+		})
+	}
+}
+
+type fakeAuditRepository struct {
+	entries []*model.AuditLog
+}
+
+func (f *fakeAuditRepository) WithTx(*gorm.DB) repository.AuditRepository { return f }
+func (f *fakeAuditRepository) Add(_ context.Context, e *model.AuditLog) error {
+	f.entries = append(f.entries, e)
+	return nil
+}
+func (f *fakeAuditRepository) List(context.Context, repository.AuditFilter, pagination.Params) (pagination.Page[model.AuditLog], error) {
+	return pagination.Page[model.AuditLog]{}, nil
+}
+
+// fakeTransactor runs fn with no database behind it.
+type fakeTransactor struct{}
+
+func (fakeTransactor) Transaction(_ context.Context, fn func(tx *gorm.DB) error) error {
+	return fn(nil)
+}
+
+func TestAPIKeyChangesAreAudited(t *testing.T) {
+	ctx := context.Background()
+	audit := &fakeAuditRepository{}
+	svc := NewAPIKeyService(&fakeAPIKeyRepository{}, audit, fakeTransactor{})
+
+	created, err := svc.Create(ctx, &CreateAPIKeyRequest{Name: "cron", Scopes: []string{"customers:read"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := svc.Revoke(ctx, created.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := svc.Revoke(ctx, created.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(audit.entries) != 2 {
+		t.Fatalf("%d audit entries, want one for create and one for the first revoke", len(audit.entries))
+	}
+	for i, action := range []string{AuditCreate, AuditUpdate} {
+		e := audit.entries[i]
+		if e.Action != action || e.EntityType != EntityAPIKey || e.EntityID != created.ID {
+			t.Errorf("entry %d = %s %s %s, want %s %s %s", i, e.Action, e.EntityType, e.EntityID, action, EntityAPIKey, created.ID)
+		}
+		raw, _ := json.Marshal(e)
+		if strings.Contains(string(raw), created.Key) || strings.Contains(string(raw), created.Hash) {
+			t.Errorf("entry %d holds the key or its hash: %s", i, raw)
+		}
+		if !strings.Contains(string(e.After), created.Prefix) {
+			t.Errorf("entry %d after = %s, want the prefix %s", i, e.After, created.Prefix)
+		}
+	}
+	if !strings.Contains(string(audit.entries[1].Changes), "revokedAt") {
+		t.Errorf("revoke changes = %s, want revokedAt", audit.entries[1].Changes)
+	}
+}
@@ -0,0 +1,112 @@
+package service
+
+import (
+	"context"
+	"customer-api/pkg/auth"
+	"customer-api/pkg/model"
+	"customer-api/pkg/pagination"
+	"customer-api/pkg/repository"
+	"customer-api/pkg/requestid"
+	"encoding/json"
+	"reflect"
+
+	"github.com/google/uuid"
+)
+
+const (
+	AuditCreate = "create"
+	AuditUpdate = "update"
+	AuditDelete = "delete"
+)
+
+const (
+	EntityCustomer    = "customer"
+	EntityProduct     = "product"
+	EntityFeedback    = "feedback"
+	EntityInteraction = "interaction"
+	EntityAPIKey      = "api_key"
+)
+
+type AuditService interface {
+	List(ctx context.Context, filter repository.AuditFilter, p pagination.Params) (pagination.Page[model.AuditLog], error)
+}
+
+type auditService struct {
+	repo repository.AuditRepository
+}
+
+// List implements AuditService.
+func (s *auditService) List(ctx context.Context, filter repository.AuditFilter, p pagination.Params) (pagination.Page[model.AuditLog], error) {
+	return s.repo.List(ctx, filter, p.WithDefaults())
+}
+
+func NewAuditService(r repository.AuditRepository) AuditService {
+	return &auditService{repo: r}
+}
+
+// change is one field of a diff.
+type change struct {
+	From any `json:"from"`
+	To   any `json:"to"`
+}
+
+// recordAudit appends an audit entry for a change made by the caller in
+// ctx. before is nil on create and after is nil on delete. Call it with a
+// repository bound to the transaction of the change.
+func recordAudit(ctx context.Context, audit repository.AuditRepository, action, entityType string, id uuid.UUID, before, after any) error {
+	entry := &model.AuditLog{
+		Actor:      auth.Subject(ctx),
+		Action:     action,
+		EntityType: entityType,
+		EntityID:   id,
+		RequestID:  requestid.FromContext(ctx),
+	}
+
+	var b, a map[string]any
+	var err error
+	if before != nil {
+		if entry.Before, b, err = snapshot(before); err != nil {
+			return err
+		}
+	}
+	if after != nil {
+		if entry.After, a, err = snapshot(after); err != nil {
+			return err
+		}
+	}
+	if b != nil && a != nil {
+		if entry.Changes, err = json.Marshal(diff(b, a)); err != nil {
+			return err
+		}
+	}
+
+	return audit.Add(ctx, entry)
+}
+
+func snapshot(v any) (model.JSON, map[string]any, error) {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil, nil, err
+	}
+	var fields map[string]any
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		return nil, nil, err
+	}
+	return raw, fields, nil
+}
+
+// diff returns the fields whose value differs between before and after.
+func diff(before, after map[string]any) map[string]change {
+	changes := make(map[string]change)
+	for k, from := range before {
+		if to, ok := after[k]; !ok || !reflect.DeepEqual(from, to) {
+			changes[k] = change{From: from, To: after[k]}
+		}
+	}
+	for k, to := range after {
+		if _, ok := before[k]; !ok {
+			changes[k] = change{To: to}
+		}
+	}
+	return changes
+}
@@ -1,35 +1,49 @@
 package service
 
 import (
+	"context"
+	"customer-api/pkg/event"
 	"customer-api/pkg/model"
+	"customer-api/pkg/pagination"
 	"customer-api/pkg/repository"
-	"log"
+	"customer-api/pkg/validation"
+	"errors"
+	"io"
 
+	"github.com/go-playground/validator/v10"
 	"github.com/google/uuid"
+	"gorm.io/gorm"
 )
 
 type CustomerService interface {
-	Create(req *CreateCustomerRequest) (*model.Customer, error)
-	Get(id uuid.UUID) (*model.Customer, error)
-	Update(id uuid.UUID, req *UpdateCustomerRequest) (*model.Customer, error)
-	Delete(id uuid.UUID) error
-	List(query string, limit, offset int) ([]model.Customer, error)
+	Create(ctx context.Context, req *CreateCustomerRequest) (*model.Customer, error)
+	Get(ctx context.Context, id uuid.UUID) (*model.Customer, error)
+	Update(ctx context.Context, id uuid.UUID, req *UpdateCustomerRequest) (*model.Customer, error)
+	Delete(ctx context.Context, id uuid.UUID) error
+	List(ctx context.Context, query string, p pagination.Params) (pagination.Page[model.Customer], error)
+	// Stream hands fn every customer matching query, for exports.
+	Stream(ctx context.Context, query string, fn func(*model.Customer) error) error
+	Import(ctx context.Context, r io.Reader, opts ImportOptions) (*ImportReport, error)
 }
 
 type service struct {
-	repo repository.CustomerRepository
+	repo     repository.CustomerRepository
+	outbox   repository.OutboxRepository
+	audit    repository.AuditRepository
+	tx       repository.Transactor
+	validate *validator.Validate
 }
 
 type CreateCustomerRequest struct {
-	Name  string `json:"name" validate:"required"`
-	Email string `json:"email" validate:"required,email"`
-	Phone string `json:"phone"`
+	Name  string `json:"name" validate:"required,max=255"`
+	Email string `json:"email" validate:"required,email,max=255"`
+	Phone string `json:"phone" validate:"max=50"`
 }
 
 type UpdateCustomerRequest struct {
-	Name  *string `json:"name" validate:"required"`
-	Email *string `json:"email" validate:"omitempty,email"`
-	Phone *string `json:"phone"`
+	Name  *string `json:"name" validate:"required,max=255"`
+	Email *string `json:"email" validate:"omitempty,email,max=255"`
+	Phone *string `json:"phone" validate:"omitempty,max=50"`
 }
 
 type CustomerResponse struct {
@@ -50,66 +64,112 @@ type CommentResponse struct {
 }
 
 // Create implements CustomerService.
-func (s *service) Create(req *CreateCustomerRequest) (*model.Customer, error) {
+func (s *service) Create(ctx context.Context, req *CreateCustomerRequest) (*model.Customer, error) {
 	c := &model.Customer{
 		Name:  req.Name,
 		Email: req.Email,
 		Phone: req.Phone,
 	}
 
-	if err := s.repo.Create(c); err != nil {
+	err := s.tx.Transaction(ctx, func(tx *gorm.DB) error {
+		if err := s.repo.WithTx(tx).Create(ctx, c); err != nil {
+			return orEmailTaken(err)
+		}
+		after := event.NewCustomerData(c)
+		if err := recordAudit(ctx, s.audit.WithTx(tx), AuditCreate, EntityCustomer, c.ID, nil, after); err != nil {
+			return err
+		}
+		return recordEvent(ctx, s.outbox.WithTx(tx), event.CustomerCreated, c.ID, after)
+	})
+	if err != nil {
 		return nil, err
 	}
 	return c, nil
 }
 
 // Delete implements CustomerService.
-func (s *service) Delete(id uuid.UUID) error {
-	_, err := s.repo.GetByID(id)
+func (s *service) Delete(ctx context.Context, id uuid.UUID) error {
+	return s.tx.Transaction(ctx, func(tx *gorm.DB) error {
+		repo := s.repo.WithTx(tx)
+		c, err := repo.GetForUpdate(ctx, id)
+		if err != nil {
+			return orNotFound(err, ErrCustomerNotFound)
+		}
+		if err := repo.Delete(ctx, id); err != nil {
+			return err
+		}
+		if err := recordAudit(ctx, s.audit.WithTx(tx), AuditDelete, EntityCustomer, id, event.NewCustomerData(c), nil); err != nil {
+			return err
+		}
+		return recordEvent(ctx, s.outbox.WithTx(tx), event.CustomerDeleted, id, event.DeletedData{ID: id})
+	})
+}
+
+// Get implements CustomerService.
+func (s *service) Get(ctx context.Context, id uuid.UUID) (*model.Customer, error) {
+	c, err := s.repo.GetByID(ctx, id)
 	if err != nil {
-		return err
+		return nil, orNotFound(err, ErrCustomerNotFound)
 	}
-
-	return s.repo.Delete(id)
+	return c, nil
 }
 
-// Get implements CustomerService.
-func (s *service) Get(id uuid.UUID) (*model.Customer, error) {
-	return s.repo.GetByID(id)
+// Stream implements CustomerService.
+func (s *service) Stream(ctx context.Context, query string, fn func(*model.Customer) error) error {
+	return s.repo.Stream(ctx, query, fn)
 }
 
 // List implements CustomerService.
-func (s *service) List(query string, limit int, offset int) ([]model.Customer, error) {
-	if limit == 0 {
-		limit = 10
-	}
-	log.Default().Printf("limit: %d", limit)
-	return s.repo.List(query, limit, offset)
+func (s *service) List(ctx context.Context, query string, p pagination.Params) (pagination.Page[model.Customer], error) {
+	return s.repo.List(ctx, query, p.WithDefaults())
 }
 
-// Update implements CustomerService.
-func (s *service) Update(id uuid.UUID, req *UpdateCustomerRequest) (*model.Customer, error) {
-	c, err := s.repo.GetByID(id)
+// Update implements CustomerService. The customer is read under a row
+// lock, so the audited before state and the order of events match the
+// order writers commit in.
+func (s *service) Update(ctx context.Context, id uuid.UUID, req *UpdateCustomerRequest) (*model.Customer, error) {
+	var c *model.Customer
+	err := s.tx.Transaction(ctx, func(tx *gorm.DB) error {
+		repo := s.repo.WithTx(tx)
+		var err error
+		if c, err = repo.GetForUpdate(ctx, id); err != nil {
+			return orNotFound(err, ErrCustomerNotFound)
+		}
+		before := event.NewCustomerData(c)
+
+		if req.Name != nil {
+			c.Name = *req.Name
+		}
+		if req.Email != nil {
+			c.Email = *req.Email
+		}
+		if req.Phone != nil {
+			c.Phone = *req.Phone
+		}
+		if err := repo.Update(ctx, c); err != nil {
+			return orEmailTaken(err)
+		}
+		after := event.NewCustomerData(c)
+		if err := recordAudit(ctx, s.audit.WithTx(tx), AuditUpdate, EntityCustomer, c.ID, before, after); err != nil {
+			return err
+		}
+		return recordEvent(ctx, s.outbox.WithTx(tx), event.CustomerUpdated, c.ID, after)
+	})
 	if err != nil {
 		return nil, err
 	}
-
-	if req.Name != nil {
-		c.Name = *req.Name
-	}
-	if req.Email != nil {
-		c.Email = *req.Email
-	}
-	if req.Phone != nil {
-		c.Phone = *req.Phone
-	}
-	if err := s.repo.Update(c); err != nil {
-		return nil, err
-	}
 	return c, nil
+}
 
+// orEmailTaken reports a unique violation, which on customers can only be
+// the email index, as ErrEmailTaken.
+func orEmailTaken(err error) error {
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		return ErrEmailTaken
+	}
+	return err
 }
 
-func NewService(r repository.CustomerRepository) CustomerService {
-	return &service{repo: r}
+func NewService(r repository.CustomerRepository, outbox repository.OutboxRepository, audit repository.AuditRepository, tx repository.Transactor) CustomerService {
+	return &service{repo: r, outbox: outbox, audit: audit, tx: tx, validate: validation.New()}
 }
@@ -0,0 +1,103 @@
+package service
+
+import (
+	"errors"
+	"sort"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// Kinds of domain error. Every error a service returns to a caller either
+// matches one of them with errors.Is or is an unexpected failure.
+var (
+	ErrNotFound   = errors.New("not found")
+	ErrConflict   = errors.New("conflict")
+	ErrValidation = errors.New("validation failed")
+	ErrForbidden  = errors.New("forbidden")
+)
+
+var (
+	ErrCustomerNotFound    = notFound("customer")
+	ErrProductNotFound     = notFound("product")
+	ErrFeedbackNotFound    = notFound("feedback")
+	ErrInteractionNotFound = notFound("interaction")
+	ErrAPIKeyNotFound      = notFound("api_key")
+
+	ErrCustomerErased = &Error{
+		Kind:    ErrConflict,
+		Code:    "customer_erased",
+		Message: "the customer's personal data was erased, so their records cannot change",
+	}
+
+	ErrEmailTaken = &Error{
+		Kind:    ErrConflict,
+		Code:    "email_taken",
+		Message: "a customer with this email already exists",
+		Fields:  map[string]string{"email": "is already taken"},
+	}
+)
+
+// Error is a domain error of one Kind with a stable Code for clients, such
+// as "customer_not_found". Fields points at the request fields at fault.
+type Error struct {
+	Kind    error
+	Code    string
+	Message string
+	Fields  map[string]string
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// Is reports whether target is e's Kind or an Error with the same Code.
+func (e *Error) Is(target error) bool {
+	if t, ok := target.(*Error); ok {
+		return t.Code == e.Code
+	}
+	return target == e.Kind
+}
+
+// onField returns a copy of e blaming the request field name.
+func (e *Error) onField(name, msg string) *Error {
+	c := *e
+	c.Fields = map[string]string{name: msg}
+	return &c
+}
+
+func notFound(entity string) *Error {
+	return &Error{
+		Kind:    ErrNotFound,
+		Code:    entity + "_not_found",
+		Message: strings.ReplaceAll(entity, "_", " ") + " not found",
+	}
+}
+
+// ValidationError carries per-field messages keyed by the JSON field name.
+type ValidationError struct {
+	Fields map[string]string
+}
+
+func (e *ValidationError) Error() string {
+	names := make([]string, 0, len(e.Fields))
+	for k := range e.Fields {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	return "validation failed: " + strings.Join(names, ", ")
+}
+
+// Is makes every ValidationError match ErrValidation.
+func (e *ValidationError) Is(target error) bool {
+	return target == ErrValidation
+}
+
+// orNotFound replaces a missing-record error from the repository with
+// notFound, leaving every other error alone.
+func orNotFound(err, notFound error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return notFound
+	}
+	return err
+}
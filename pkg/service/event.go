@@ -0,0 +1,24 @@
+package service
+
+import (
+	"context"
+	"customer-api/pkg/auth"
+	"customer-api/pkg/event"
+	"customer-api/pkg/repository"
+
+	"github.com/google/uuid"
+)
+
+// recordEvent adds a domain event, attributed to the caller in ctx, to the
+// outbox. Call it with an outbox bound to the transaction of the change so
+// both commit or neither does.
+func recordEvent(ctx context.Context, outbox repository.OutboxRepository, typ string, id uuid.UUID, data any) error {
+	e, err := event.New(typ, id, data)
+	if err != nil {
+		return err
+	}
+	if p := auth.FromContext(ctx); p != nil {
+		e.Actor = p.Subject
+	}
+	return outbox.Add(ctx, e)
+}
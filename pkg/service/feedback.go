@@ -0,0 +1,196 @@
+package service
+
+import (
+	"context"
+	"customer-api/pkg/event"
+	"customer-api/pkg/model"
+	"customer-api/pkg/pagination"
+	"customer-api/pkg/repository"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	MinRating = 1
+	MaxRating = 5
+)
+
+type FeedbackService interface {
+	Create(ctx context.Context, in *FeedbackInput) (*FeedbackDetail, error)
+	Get(ctx context.Context, id uuid.UUID) (*model.Feedback, error)
+	Update(ctx context.Context, id uuid.UUID, in *FeedbackInput) (*model.Feedback, error)
+	Delete(ctx context.Context, id uuid.UUID) error
+	List(ctx context.Context, filter repository.FeedbackFilter, p pagination.Params) (pagination.Page[model.Feedback], error)
+	// Stream hands fn all feedback matching filter, for exports.
+	Stream(ctx context.Context, filter repository.FeedbackFilter, fn func(*model.Feedback) error) error
+}
+
+type feedbackService struct {
+	repo         repository.FeedbackRepository
+	customerRepo repository.CustomerRepository
+	productRepo  repository.ProductRepository
+	outbox       repository.OutboxRepository
+	audit        repository.AuditRepository
+	tx           repository.Transactor
+}
+
+// FeedbackInput is a feedback submission with already parsed ids.
+type FeedbackInput struct {
+	CustomerID uuid.UUID
+	ProductID  uuid.UUID
+	Rating     int
+	Comment    string
+}
+
+// FeedbackDetail is a stored feedback together with who gave it and for what.
+type FeedbackDetail struct {
+	Feedback *model.Feedback
+	Customer *model.Customer
+	Product  *model.Product
+}
+
+// Create implements FeedbackService.
+func (s *feedbackService) Create(ctx context.Context, in *FeedbackInput) (*FeedbackDetail, error) {
+	customer, product, err := s.check(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+
+	fd := &model.Feedback{
+		ID:         uuid.New(),
+		CustomerID: in.CustomerID,
+		ProductID:  in.ProductID,
+		Rating:     in.Rating,
+		Comment:    in.Comment,
+	}
+	err = s.tx.Transaction(ctx, func(tx *gorm.DB) error {
+		if err := s.repo.WithTx(tx).Create(ctx, fd); err != nil {
+			return err
+		}
+		after := event.NewFeedbackData(fd)
+		if err := recordAudit(ctx, s.audit.WithTx(tx), AuditCreate, EntityFeedback, fd.ID, nil, after); err != nil {
+			return err
+		}
+		return recordEvent(ctx, s.outbox.WithTx(tx), event.FeedbackSubmitted, fd.ID, after)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &FeedbackDetail{
+		Feedback: fd,
+		Customer: customer,
+		Product:  product,
+	}, nil
+}
+
+// Delete implements FeedbackService.
+func (s *feedbackService) Delete(ctx context.Context, id uuid.UUID) error {
+	return s.tx.Transaction(ctx, func(tx *gorm.DB) error {
+		repo := s.repo.WithTx(tx)
+		fd, err := repo.GetForUpdate(ctx, id)
+		if err != nil {
+			return orNotFound(err, ErrFeedbackNotFound)
+		}
+		if err := checkNotErased(ctx, s.customerRepo.WithTx(tx), fd.CustomerID); err != nil {
+			return err
+		}
+		if err := repo.Delete(ctx, id); err != nil {
+			return err
+		}
+		if err := recordAudit(ctx, s.audit.WithTx(tx), AuditDelete, EntityFeedback, id, event.NewFeedbackData(fd), nil); err != nil {
+			return err
+		}
+		return recordEvent(ctx, s.outbox.WithTx(tx), event.FeedbackDeleted, id, event.DeletedData{ID: id})
+	})
+}
+
+// Get implements FeedbackService.
+func (s *feedbackService) Get(ctx context.Context, id uuid.UUID) (*model.Feedback, error) {
+	fd, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, orNotFound(err, ErrFeedbackNotFound)
+	}
+	return fd, nil
+}
+
+// Stream implements FeedbackService.
+func (s *feedbackService) Stream(ctx context.Context, filter repository.FeedbackFilter, fn func(*model.Feedback) error) error {
+	return s.repo.Stream(ctx, filter, fn)
+}
+
+// List implements FeedbackService.
+func (s *feedbackService) List(ctx context.Context, filter repository.FeedbackFilter, p pagination.Params) (pagination.Page[model.Feedback], error) {
+	return s.repo.List(ctx, filter, p.WithDefaults())
+}
+
+// Update implements FeedbackService. Like customers, the feedback is read
+// under a row lock.
+func (s *feedbackService) Update(ctx context.Context, id uuid.UUID, in *FeedbackInput) (*model.Feedback, error) {
+	if _, _, err := s.check(ctx, in); err != nil {
+		return nil, err
+	}
+
+	var fd *model.Feedback
+	err := s.tx.Transaction(ctx, func(tx *gorm.DB) error {
+		repo := s.repo.WithTx(tx)
+		var err error
+		if fd, err = repo.GetForUpdate(ctx, id); err != nil {
+			return orNotFound(err, ErrFeedbackNotFound)
+		}
+		if err := checkNotErased(ctx, s.customerRepo.WithTx(tx), fd.CustomerID); err != nil {
+			return err
+		}
+		before := event.NewFeedbackData(fd)
+
+		fd.CustomerID = in.CustomerID
+		fd.ProductID = in.ProductID
+		fd.Rating = in.Rating
+		fd.Comment = in.Comment
+		if err := repo.Update(ctx, fd); err != nil {
+			return err
+		}
+		after := event.NewFeedbackData(fd)
+		if err := recordAudit(ctx, s.audit.WithTx(tx), AuditUpdate, EntityFeedback, fd.ID, before, after); err != nil {
+			return err
+		}
+		return recordEvent(ctx, s.outbox.WithTx(tx), event.FeedbackUpdated, fd.ID, after)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return fd, nil
+}
+
+// check validates the rating and loads the referenced customer and product.
+func (s *feedbackService) check(ctx context.Context, in *FeedbackInput) (*model.Customer, *model.Product, error) {
+	if in.Rating < MinRating || in.Rating > MaxRating {
+		return nil, nil, &ValidationError{Fields: map[string]string{
+			"rating": "must be between 1 and 5",
+		}}
+	}
+
+	customer, err := s.customerRepo.GetByID(ctx, in.CustomerID)
+	if err != nil {
+		return nil, nil, orNotFound(err, ErrCustomerNotFound.onField("customerId", "does not exist"))
+	}
+
+	product, err := s.productRepo.GetByID(ctx, in.ProductID)
+	if err != nil {
+		return nil, nil, orNotFound(err, ErrProductNotFound.onField("productId", "does not exist"))
+	}
+
+	return customer, product, nil
+}
+
+func NewFeedbackService(r repository.FeedbackRepository, customerRepo repository.CustomerRepository, productRepo repository.ProductRepository, outbox repository.OutboxRepository, audit repository.AuditRepository, tx repository.Transactor) FeedbackService {
+	return &feedbackService{
+		repo:         r,
+		customerRepo: customerRepo,
+		productRepo:  productRepo,
+		outbox:       outbox,
+		audit:        audit,
+		tx:           tx,
+	}
+}
@@ -0,0 +1,414 @@
+package service
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"customer-api/pkg/event"
+	"customer-api/pkg/model"
+	"customer-api/pkg/validation"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// ImportFormat is the encoding of a customer import file.
+type ImportFormat string
+
+const (
+	ImportCSV    ImportFormat = "csv"
+	ImportNDJSON ImportFormat = "ndjson"
+)
+
+// DefaultImportBatchSize is how many rows share one transaction.
+const DefaultImportBatchSize = 500
+
+// maxNDJSONLine bounds one NDJSON row.
+const maxNDJSONLine = 1 << 20
+
+// Row error codes.
+const (
+	RowMalformed   = "malformed_row"
+	RowInvalid     = "validation_failed"
+	RowDuplicate   = "duplicate_email"
+	RowEmailTaken  = "email_taken"
+	csvColumnName  = "name"
+	csvColumnEmail = "email"
+	csvColumnPhone = "phone"
+)
+
+var errDryRun = errors.New("dry run")
+
+type ImportOptions struct {
+	Format    ImportFormat
+	DryRun    bool
+	BatchSize int
+}
+
+// ImportReport summarises an import: every row is created, updated,
+// unchanged or failed, and each failure is listed in Errors. A dry run
+// reports what a real run would do without keeping any change.
+type ImportReport struct {
+	DryRun    bool       `json:"dryRun"`
+	Rows      int        `json:"rows"`
+	Created   int        `json:"created"`
+	Updated   int        `json:"updated"`
+	Unchanged int        `json:"unchanged"`
+	Failed    int        `json:"failed"`
+	Errors    []RowError `json:"errors"`
+}
+
+// RowError explains why one row was skipped. Line is the row's line in the
+// file, counting the CSV header.
+type RowError struct {
+	Line   int               `json:"line"`
+	Email  string            `json:"email,omitempty"`
+	Code   string            `json:"code"`
+	Detail string            `json:"detail,omitempty"`
+	Fields map[string]string `json:"fields,omitempty"`
+}
+
+func (e *RowError) Error() string {
+	return fmt.Sprintf("line %d: %s", e.Line, e.Code)
+}
+
+func (r *ImportReport) fail(e RowError) {
+	r.Failed++
+	r.Errors = append(r.Errors, e)
+}
+
+type importRow struct {
+	line int
+	req  CreateCustomerRequest
+}
+
+// Import implements CustomerService. Rows are validated like
+// CreateCustomerRequest and upserted by email, BatchSize rows per
+// transaction. An empty phone keeps the stored one. A failing row is
+// reported and skipped; a read or database error stops the import, keeping
+// the batches already committed, and is returned with the report of them.
+func (s *service) Import(ctx context.Context, r io.Reader, opts ImportOptions) (*ImportReport, error) {
+	rows, err := newRowReader(r, opts.Format)
+	if err != nil {
+		return nil, err
+	}
+	size := opts.BatchSize
+	if size <= 0 {
+		size = DefaultImportBatchSize
+	}
+
+	report := &ImportReport{DryRun: opts.DryRun, Errors: []RowError{}}
+	defer func() {
+		sort.SliceStable(report.Errors, func(i, j int) bool {
+			return report.Errors[i].Line < report.Errors[j].Line
+		})
+	}()
+	seen := make(map[string]int)
+	batch := make([]importRow, 0, size)
+	for {
+		line, req, err := rows.next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		var rowErr *RowError
+		if errors.As(err, &rowErr) {
+			report.Rows++
+			report.fail(*rowErr)
+			continue
+		}
+		if err != nil {
+			return report, err
+		}
+
+		report.Rows++
+		if fields := validation.Fields(s.validate.Struct(req)); fields != nil {
+			report.fail(RowError{Line: line, Email: req.Email, Code: RowInvalid, Fields: fields})
+			continue
+		}
+		if first, dup := seen[req.Email]; dup {
+			report.fail(RowError{Line: line, Email: req.Email, Code: RowDuplicate,
+				Fields: map[string]string{"email": fmt.Sprintf("already on line %d", first)}})
+			continue
+		}
+		seen[req.Email] = line
+
+		batch = append(batch, importRow{line: line, req: req})
+		if len(batch) == size {
+			if err := s.importBatch(ctx, batch, opts.DryRun, report); err != nil {
+				return report, err
+			}
+			batch = batch[:0]
+		}
+	}
+	if err := s.importBatch(ctx, batch, opts.DryRun, report); err != nil {
+		return report, err
+	}
+	return report, nil
+}
+
+// importBatch upserts batch in one transaction, with the same audit
+// entries and events as single creates and updates. A dry run rolls the
+// transaction back so the database still vets every statement.
+func (s *service) importBatch(ctx context.Context, batch []importRow, dryRun bool, report *ImportReport) error {
+	if len(batch) == 0 {
+		return nil
+	}
+
+	var created, updated, unchanged int
+	var failed []RowError
+	err := s.tx.Transaction(ctx, func(tx *gorm.DB) error {
+		repo, audit, outbox := s.repo.WithTx(tx), s.audit.WithTx(tx), s.outbox.WithTx(tx)
+
+		emails := make([]string, len(batch))
+		for i, row := range batch {
+			emails[i] = row.req.Email
+		}
+		found, err := repo.FindByEmails(ctx, emails)
+		if err != nil {
+			return err
+		}
+		existing := make(map[string]*model.Customer, len(found))
+		for i := range found {
+			existing[found[i].Email] = &found[i]
+		}
+
+		var fresh []*model.Customer
+		lines := make(map[*model.Customer]int)
+		for _, row := range batch {
+			c, ok := existing[row.req.Email]
+			switch {
+			case !ok:
+				c := &model.Customer{Name: row.req.Name, Email: row.req.Email, Phone: row.req.Phone}
+				fresh = append(fresh, c)
+				lines[c] = row.line
+			case c.DeletedAt.Valid:
+				failed = append(failed, RowError{Line: row.line, Email: row.req.Email, Code: RowEmailTaken,
+					Fields: map[string]string{"email": "belongs to a deleted customer"}})
+			default:
+				before := event.NewCustomerData(c)
+				c.Name = row.req.Name
+				if row.req.Phone != "" {
+					c.Phone = row.req.Phone
+				}
+				after := event.NewCustomerData(c)
+				if after == before {
+					unchanged++
+					continue
+				}
+				if err := repo.Update(ctx, c); err != nil {
+					return err
+				}
+				if err := recordAudit(ctx, audit, AuditUpdate, EntityCustomer, c.ID, before, after); err != nil {
+					return err
+				}
+				if err := recordEvent(ctx, outbox, event.CustomerUpdated, c.ID, after); err != nil {
+					return err
+				}
+				updated++
+			}
+		}
+
+		// a concurrent create can take an email after FindByEmails
+		inserted, err := repo.CreateBatch(ctx, fresh)
+		if err != nil {
+			return err
+		}
+		for _, c := range inserted {
+			delete(lines, c)
+		}
+		for _, c := range fresh {
+			if line, taken := lines[c]; taken {
+				failed = append(failed, RowError{Line: line, Email: c.Email, Code: RowEmailTaken,
+					Fields: map[string]string{"email": "is already taken"}})
+			}
+		}
+		for _, c := range inserted {
+			after := event.NewCustomerData(c)
+			if err := recordAudit(ctx, audit, AuditCreate, EntityCustomer, c.ID, nil, after); err != nil {
+				return err
+			}
+			if err := recordEvent(ctx, outbox, event.CustomerCreated, c.ID, after); err != nil {
+				return err
+			}
+		}
+		created = len(inserted)
+
+		if dryRun {
+			return errDryRun
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errDryRun) {
+		return err
+	}
+
+	report.Created += created
+	report.Updated += updated
+	report.Unchanged += unchanged
+	for _, e := range failed {
+		report.fail(e)
+	}
+	return nil
+}
+
+// rowReader yields import rows. next returns io.EOF at the end, a
+// *RowError for a row that cannot be decoded, and any other error when
+// the file cannot be read on.
+type rowReader interface {
+	next() (line int, req CreateCustomerRequest, err error)
+}
+
+func newRowReader(r io.Reader, format ImportFormat) (rowReader, error) {
+	switch format {
+	case ImportCSV:
+		return newCSVRows(r)
+	case ImportNDJSON:
+		return &ndjsonRows{r: bufio.NewReaderSize(r, 64*1024)}, nil
+	default:
+		return nil, &ValidationError{Fields: map[string]string{"format": "must be csv or ndjson"}}
+	}
+}
+
+// csvRows reads a CSV file whose header names the name, email and
+// optional phone columns, in any order.
+type csvRows struct {
+	r    *csv.Reader
+	cols map[string]int
+}
+
+func newCSVRows(r io.Reader) (*csvRows, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+
+	header, err := cr.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, &ValidationError{Fields: map[string]string{"file": "is empty"}}
+	}
+	var parseErr *csv.ParseError
+	if errors.As(err, &parseErr) {
+		return nil, &ValidationError{Fields: map[string]string{"header": "is not valid CSV"}}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	cols := make(map[string]int, len(header))
+	for i, h := range header {
+		name := strings.ToLower(strings.TrimSpace(strings.TrimPrefix(h, "\ufeff")))
+		switch name {
+		case csvColumnName, csvColumnEmail, csvColumnPhone:
+			cols[name] = i
+		default:
+			return nil, &ValidationError{Fields: map[string]string{"header": fmt.Sprintf("unknown column %q", h)}}
+		}
+	}
+	for _, required := range []string{csvColumnName, csvColumnEmail} {
+		if _, ok := cols[required]; !ok {
+			return nil, &ValidationError{Fields: map[string]string{"header": "missing column " + required}}
+		}
+	}
+	return &csvRows{r: cr, cols: cols}, nil
+}
+
+func (c *csvRows) next() (int, CreateCustomerRequest, error) {
+	rec, err := c.r.Read()
+	var parseErr *csv.ParseError
+	if errors.As(err, &parseErr) {
+		return parseErr.StartLine, CreateCustomerRequest{}, &RowError{
+			Line: parseErr.StartLine, Code: RowMalformed, Detail: parseErr.Err.Error(),
+		}
+	}
+	if err != nil {
+		return 0, CreateCustomerRequest{}, err
+	}
+
+	line, _ := c.r.FieldPos(0)
+	return line, CreateCustomerRequest{
+		Name:  c.field(rec, csvColumnName),
+		Email: c.field(rec, csvColumnEmail),
+		Phone: c.field(rec, csvColumnPhone),
+	}, nil
+}
+
+func (c *csvRows) field(rec []string, col string) string {
+	i, ok := c.cols[col]
+	if !ok || i >= len(rec) {
+		return ""
+	}
+	return strings.TrimSpace(rec[i])
+}
+
+// ndjsonRows reads one JSON object per line; blank lines are skipped and
+// lines longer than maxNDJSONLine are reported without being decoded.
+type ndjsonRows struct {
+	r    *bufio.Reader
+	line int
+}
+
+func (n *ndjsonRows) next() (int, CreateCustomerRequest, error) {
+	for {
+		text, tooLong, err := n.readLine()
+		if err != nil {
+			return n.line, CreateCustomerRequest{}, err
+		}
+		n.line++
+		if tooLong {
+			return n.line, CreateCustomerRequest{}, &RowError{
+				Line: n.line, Code: RowMalformed, Detail: fmt.Sprintf("longer than %d bytes", maxNDJSONLine),
+			}
+		}
+		text = bytes.TrimSpace(text)
+		if len(text) == 0 {
+			continue
+		}
+
+		var req CreateCustomerRequest
+		if err := json.Unmarshal(text, &req); err != nil {
+			rowErr := &RowError{Line: n.line, Code: RowMalformed, Detail: "not a JSON object"}
+			var typeErr *json.UnmarshalTypeError
+			if errors.As(err, &typeErr) && typeErr.Field != "" {
+				rowErr.Code = RowInvalid
+				rowErr.Detail = ""
+				rowErr.Fields = map[string]string{typeErr.Field: "has the wrong type"}
+			}
+			return n.line, req, rowErr
+		}
+		req.Name = strings.TrimSpace(req.Name)
+		req.Email = strings.TrimSpace(req.Email)
+		req.Phone = strings.TrimSpace(req.Phone)
+		return n.line, req, nil
+	}
+}
+
+// readLine returns the next line without its newline, or io.EOF after the
+// last one. A line over maxNDJSONLine is read to its end but not kept.
+func (n *ndjsonRows) readLine() (line []byte, tooLong bool, err error) {
+	size := 0
+	for {
+		chunk, err := n.r.ReadSlice('\n')
+		size += len(chunk)
+		if size <= maxNDJSONLine+1 {
+			line = append(line, chunk...)
+		}
+		if errors.Is(err, bufio.ErrBufferFull) {
+			continue
+		}
+		if errors.Is(err, io.EOF) && size > 0 {
+			err = nil
+		}
+		if bytes.HasSuffix(chunk, []byte("\n")) {
+			size--
+		}
+		if size > maxNDJSONLine {
+			return nil, true, err
+		}
+		return bytes.TrimSuffix(line, []byte("\n")), false, err
+	}
+}
@@ -0,0 +1,160 @@
+package service
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+// readRow is what a rowReader yielded for one row.
+type readRow struct {
+	line  int
+	email string
+	code  string
+}
+
+func readAll(t *testing.T, rows rowReader) []readRow {
+	t.Helper()
+	var got []readRow
+	for {
+		line, req, err := rows.next()
+		if errors.Is(err, io.EOF) {
+			return got
+		}
+		var rowErr *RowError
+		switch {
+		case errors.As(err, &rowErr):
+			if rowErr.Line != line {
+				t.Errorf("RowError line %d, next returned line %d", rowErr.Line, line)
+			}
+			got = append(got, readRow{line: line, code: rowErr.Code})
+		case err != nil:
+			t.Fatalf("next: %v", err)
+		default:
+			got = append(got, readRow{line: line, email: req.Email})
+		}
+	}
+}
+
+func equalRows(a, b []readRow) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestCSVRows(t *testing.T) {
+	tests := []struct {
+		name string
+		file string
+		want []readRow
+	}{
+		{"header counts as line 1", "name,email\nAlice,a@x.io\nBob,b@x.io\n",
+			[]readRow{{line: 2, email: "a@x.io"}, {line: 3, email: "b@x.io"}}},
+		{"columns in any order", "\ufeffPhone, Email ,name\n0812,a@x.io,Alice\n",
+			[]readRow{{line: 2, email: "a@x.io"}}},
+		{"quoted newline", "name,email\n\"Al\nice\",a@x.io\nBob,b@x.io\n",
+			[]readRow{{line: 2, email: "a@x.io"}, {line: 4, email: "b@x.io"}}},
+		{"short row", "name,email,phone\nAlice,a@x.io\n",
+			[]readRow{{line: 2, email: "a@x.io"}}},
+		{"bare quote", "name,email\nAl\"ice,a@x.io\nBob,b@x.io\n",
+			[]readRow{{line: 2, code: RowMalformed}, {line: 3, email: "b@x.io"}}},
+		{"no trailing newline", "name,email\nAlice,a@x.io",
+			[]readRow{{line: 2, email: "a@x.io"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rows, err := newRowReader(strings.NewReader(tt.file), ImportCSV)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := readAll(t, rows); !equalRows(got, tt.want) {
+				t.Errorf("rows = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCSVHeader(t *testing.T) {
+	tests := map[string]string{
+		"empty":          "",
+		"unknown column": "name,email,age\n",
+		"missing email":  "name,phone\n",
+		"invalid":        "name,\"email\n",
+	}
+	for name, file := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := newRowReader(strings.NewReader(file), ImportCSV)
+			var ve *ValidationError
+			if !errors.As(err, &ve) {
+				t.Errorf("error = %v, want a ValidationError", err)
+			}
+		})
+	}
+}
+
+func TestNDJSONRows(t *testing.T) {
+	long := `{"name":"` + strings.Repeat("a", maxNDJSONLine) + `","email":"long@x.io"}`
+
+	tests := []struct {
+		name string
+		file string
+		want []readRow
+	}{
+		{"one object per line", "{\"email\":\"a@x.io\"}\n{\"email\":\"b@x.io\"}\n",
+			[]readRow{{line: 1, email: "a@x.io"}, {line: 2, email: "b@x.io"}}},
+		{"blank lines are counted", "\n{\"email\":\"a@x.io\"}\n  \n{\"email\":\"b@x.io\"}",
+			[]readRow{{line: 2, email: "a@x.io"}, {line: 4, email: "b@x.io"}}},
+		{"crlf", "{\"email\":\" a@x.io \"}\r\n{\"email\":\"b@x.io\"}\r\n",
+			[]readRow{{line: 1, email: "a@x.io"}, {line: 2, email: "b@x.io"}}},
+		{"not json", "{\"email\":\"a@x.io\"}\nhello\n{\"email\":\"b@x.io\"}\n",
+			[]readRow{{line: 1, email: "a@x.io"}, {line: 2, code: RowMalformed}, {line: 3, email: "b@x.io"}}},
+		{"wrong type", "{\"email\":1}\n",
+			[]readRow{{line: 1, code: RowInvalid}}},
+		{"line too long", "{\"email\":\"a@x.io\"}\n" + long + "\n{\"email\":\"b@x.io\"}\n",
+			[]readRow{{line: 1, email: "a@x.io"}, {line: 2, code: RowMalformed}, {line: 3, email: "b@x.io"}}},
+		{"last line too long", "{\"email\":\"a@x.io\"}\n" + long,
+			[]readRow{{line: 1, email: "a@x.io"}, {line: 2, code: RowMalformed}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rows, err := newRowReader(strings.NewReader(tt.file), ImportNDJSON)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := readAll(t, rows); !equalRows(got, tt.want) {
+				t.Errorf("rows = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNDJSONLineLimit(t *testing.T) {
+	object := func(size int) string {
+		pad := size - len(`{"email":"a@x.io","name":""}`)
+		return `{"email":"a@x.io","name":"` + strings.Repeat("a", pad) + `"}`
+	}
+	fits, over := object(maxNDJSONLine), object(maxNDJSONLine+1)
+
+	tests := []struct {
+		file string
+		want readRow
+	}{
+		{fits, readRow{line: 1, email: "a@x.io"}},
+		{fits + "\n", readRow{line: 1, email: "a@x.io"}},
+		{over, readRow{line: 1, code: RowMalformed}},
+		{over + "\n", readRow{line: 1, code: RowMalformed}},
+	}
+	for _, tt := range tests {
+		rows, _ := newRowReader(strings.NewReader(tt.file), ImportNDJSON)
+		if got := readAll(t, rows); !equalRows(got, []readRow{tt.want}) {
+			t.Errorf("%d byte line: rows = %+v, want %+v", len(tt.file), got, tt.want)
+		}
+	}
+}
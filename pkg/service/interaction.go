@@ -0,0 +1,166 @@
+package service
+
+import (
+	"context"
+	"customer-api/pkg/model"
+	"customer-api/pkg/pagination"
+	"customer-api/pkg/repository"
+	"customer-api/pkg/validation"
+	"slices"
+	"strings"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Channels lists the contact channels an interaction can be logged against.
+// Requests check it with the channel validate tag.
+var Channels = []string{"phone", "email", "chat", "sms", "in_person", "social"}
+
+func init() {
+	validation.RegisterEnum("channel", Channels)
+}
+
+// ErrInvalidChannel rejects a channel filter outside Channels.
+var ErrInvalidChannel = &ValidationError{Fields: map[string]string{
+	"channel": "must be one of: " + strings.Join(Channels, ", "),
+}}
+
+type InteractionService interface {
+	Create(ctx context.Context, customerID uuid.UUID, req *CreateInteractionRequest) (*model.Interaction, error)
+	Get(ctx context.Context, id uuid.UUID) (*model.Interaction, error)
+	Update(ctx context.Context, id uuid.UUID, req *UpdateInteractionRequest) (*model.Interaction, error)
+	Delete(ctx context.Context, id uuid.UUID) error
+	List(ctx context.Context, filter repository.InteractionFilter, p pagination.Params) (pagination.Page[model.Interaction], error)
+	// Stream hands fn every interaction matching filter, for exports.
+	Stream(ctx context.Context, filter repository.InteractionFilter, fn func(*model.Interaction) error) error
+}
+
+type interactionService struct {
+	repo         repository.InteractionRepository
+	customerRepo repository.CustomerRepository
+	audit        repository.AuditRepository
+	tx           repository.Transactor
+}
+
+type CreateInteractionRequest struct {
+	Channel     string `json:"channel" validate:"required,channel"`
+	Description string `json:"description" validate:"required"`
+}
+
+type UpdateInteractionRequest struct {
+	Channel     *string `json:"channel" validate:"omitempty,channel"`
+	Description *string `json:"description" validate:"omitempty,min=1"`
+}
+
+// Create implements InteractionService.
+func (s *interactionService) Create(ctx context.Context, customerID uuid.UUID, req *CreateInteractionRequest) (*model.Interaction, error) {
+	if _, err := s.customerRepo.GetByID(ctx, customerID); err != nil {
+		return nil, orNotFound(err, ErrCustomerNotFound)
+	}
+
+	in := &model.Interaction{
+		CustomerID:  customerID,
+		Channel:     req.Channel,
+		Description: req.Description,
+	}
+	err := s.tx.Transaction(ctx, func(tx *gorm.DB) error {
+		if err := s.repo.WithTx(tx).Create(ctx, in); err != nil {
+			return err
+		}
+		return recordAudit(ctx, s.audit.WithTx(tx), AuditCreate, EntityInteraction, in.ID, nil, interactionAudit(in))
+	})
+	if err != nil {
+		return nil, err
+	}
+	return in, nil
+}
+
+// Delete implements InteractionService.
+func (s *interactionService) Delete(ctx context.Context, id uuid.UUID) error {
+	return s.tx.Transaction(ctx, func(tx *gorm.DB) error {
+		repo := s.repo.WithTx(tx)
+		in, err := repo.GetForUpdate(ctx, id)
+		if err != nil {
+			return orNotFound(err, ErrInteractionNotFound)
+		}
+		if err := checkNotErased(ctx, s.customerRepo.WithTx(tx), in.CustomerID); err != nil {
+			return err
+		}
+		if err := repo.Delete(ctx, id); err != nil {
+			return err
+		}
+		return recordAudit(ctx, s.audit.WithTx(tx), AuditDelete, EntityInteraction, id, interactionAudit(in), nil)
+	})
+}
+
+// Get implements InteractionService.
+func (s *interactionService) Get(ctx context.Context, id uuid.UUID) (*model.Interaction, error) {
+	in, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, orNotFound(err, ErrInteractionNotFound)
+	}
+	return in, nil
+}
+
+// List implements InteractionService.
+func (s *interactionService) List(ctx context.Context, filter repository.InteractionFilter, p pagination.Params) (pagination.Page[model.Interaction], error) {
+	if filter.Channel != "" && !slices.Contains(Channels, filter.Channel) {
+		return pagination.Page[model.Interaction]{}, ErrInvalidChannel
+	}
+	return s.repo.List(ctx, filter, p.WithDefaults())
+}
+
+// Stream implements InteractionService.
+func (s *interactionService) Stream(ctx context.Context, filter repository.InteractionFilter, fn func(*model.Interaction) error) error {
+	if filter.Channel != "" && !slices.Contains(Channels, filter.Channel) {
+		return ErrInvalidChannel
+	}
+	return s.repo.Stream(ctx, filter, fn)
+}
+
+// Update implements InteractionService. The interaction is read under a
+// row lock, so the audited before state is what this update overwrites.
+func (s *interactionService) Update(ctx context.Context, id uuid.UUID, req *UpdateInteractionRequest) (*model.Interaction, error) {
+	var in *model.Interaction
+	err := s.tx.Transaction(ctx, func(tx *gorm.DB) error {
+		repo := s.repo.WithTx(tx)
+		var err error
+		if in, err = repo.GetForUpdate(ctx, id); err != nil {
+			return orNotFound(err, ErrInteractionNotFound)
+		}
+		if err := checkNotErased(ctx, s.customerRepo.WithTx(tx), in.CustomerID); err != nil {
+			return err
+		}
+		before := interactionAudit(in)
+
+		if req.Channel != nil {
+			in.Channel = *req.Channel
+		}
+		if req.Description != nil {
+			in.Description = *req.Description
+		}
+		if err := repo.Update(ctx, in); err != nil {
+			return err
+		}
+		return recordAudit(ctx, s.audit.WithTx(tx), AuditUpdate, EntityInteraction, in.ID, before, interactionAudit(in))
+	})
+	if err != nil {
+		return nil, err
+	}
+	return in, nil
+}
+
+// interactionAudit is the audited state of an interaction.
+func interactionAudit(in *model.Interaction) map[string]any {
+	return map[string]any{"customerId": in.CustomerID, "channel": in.Channel, "description": in.Description}
+}
+
+func NewInteractionService(r repository.InteractionRepository, customerRepo repository.CustomerRepository, audit repository.AuditRepository, tx repository.Transactor) InteractionService {
+	return &interactionService{
+		repo:         r,
+		customerRepo: customerRepo,
+		audit:        audit,
+		tx:           tx,
+	}
+}
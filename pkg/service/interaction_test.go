@@ -0,0 +1,80 @@
+package service
+
+import (
+	"context"
+	"customer-api/pkg/model"
+	"customer-api/pkg/repository"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type fakeCustomerRepository struct {
+	repository.CustomerRepository
+	erased map[uuid.UUID]bool
+}
+
+func (f *fakeCustomerRepository) WithTx(*gorm.DB) repository.CustomerRepository { return f }
+
+func (f *fakeCustomerRepository) IsErased(_ context.Context, id uuid.UUID) (bool, error) {
+	return f.erased[id], nil
+}
+
+type fakeInteractionRepository struct {
+	repository.InteractionRepository
+	rows    map[uuid.UUID]*model.Interaction
+	written int
+}
+
+func (f *fakeInteractionRepository) WithTx(*gorm.DB) repository.InteractionRepository { return f }
+
+func (f *fakeInteractionRepository) GetForUpdate(_ context.Context, id uuid.UUID) (*model.Interaction, error) {
+	in, ok := f.rows[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	cp := *in
+	return &cp, nil
+}
+
+func (f *fakeInteractionRepository) Update(context.Context, *model.Interaction) error {
+	f.written++
+	return nil
+}
+
+func (f *fakeInteractionRepository) Delete(context.Context, uuid.UUID) error {
+	f.written++
+	return nil
+}
+
+func TestInteractionsOfErasedCustomerCannotChange(t *testing.T) {
+	ctx := context.Background()
+	erased, active := uuid.New(), uuid.New()
+	locked, open := uuid.New(), uuid.New()
+	repo := &fakeInteractionRepository{rows: map[uuid.UUID]*model.Interaction{
+		locked: {ID: locked, CustomerID: erased, Channel: "email"},
+		open:   {ID: open, CustomerID: active, Channel: "email"},
+	}}
+	audit := &fakeAuditRepository{}
+	svc := NewInteractionService(repo, &fakeCustomerRepository{erased: map[uuid.UUID]bool{erased: true}}, audit, fakeTransactor{})
+
+	description := "called back about +66 81 234 5678"
+	if _, err := svc.Update(ctx, locked, &UpdateInteractionRequest{Description: &description}); !errors.Is(err, ErrCustomerErased) {
+		t.Errorf("Update = %v, want %v", err, ErrCustomerErased)
+	}
+	if err := svc.Delete(ctx, locked); !errors.Is(err, ErrCustomerErased) {
+		t.Errorf("Delete = %v, want %v", err, ErrCustomerErased)
+	}
+	if repo.written != 0 || len(audit.entries) != 0 {
+		t.Fatalf("%d writes and %d audit entries for an erased customer, want none", repo.written, len(audit.entries))
+	}
+
+	if _, err := svc.Update(ctx, open, &UpdateInteractionRequest{Description: &description}); err != nil {
+		t.Fatalf("Update of an active customer's interaction: %v", err)
+	}
+	if repo.written != 1 {
+		t.Errorf("%d writes, want 1", repo.written)
+	}
+}
@@ -0,0 +1,118 @@
+package service
+
+import (
+	"context"
+	"customer-api/pkg/event"
+	"customer-api/pkg/model"
+	"customer-api/pkg/repository"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	// AuditAccess records that a customer's personal data was handed out.
+	AuditAccess = "access"
+	// AuditErase records that a customer's personal data was anonymised.
+	AuditErase = "erase"
+)
+
+// PrivacyService answers PDPA data subject requests.
+type PrivacyService interface {
+	// Export bundles everything stored about a customer, deleted records
+	// included.
+	Export(ctx context.Context, customerID uuid.UUID) (*PersonalData, error)
+	// Erase irreversibly anonymises a customer. Erasing an erased customer
+	// changes nothing.
+	Erase(ctx context.Context, customerID uuid.UUID) (*ErasureResult, error)
+}
+
+type privacyService struct {
+	repo   repository.PrivacyRepository
+	outbox repository.OutboxRepository
+	audit  repository.AuditRepository
+	tx     repository.Transactor
+}
+
+// PersonalData is the answer to a subject access request.
+type PersonalData struct {
+	ExportedAt   time.Time           `json:"exportedAt"`
+	Customer     model.Customer      `json:"customer"`
+	Feedbacks    []model.Feedback    `json:"feedbacks"`
+	Interactions []model.Interaction `json:"interactions"`
+}
+
+type ErasureResult struct {
+	CustomerID uuid.UUID `json:"customerId"`
+	ErasedAt   time.Time `json:"erasedAt"`
+}
+
+// Export implements PrivacyService.
+func (s *privacyService) Export(ctx context.Context, customerID uuid.UUID) (*PersonalData, error) {
+	data, err := s.repo.Collect(ctx, customerID)
+	if err != nil {
+		return nil, orNotFound(err, ErrCustomerNotFound)
+	}
+	if err := recordAudit(ctx, s.audit, AuditAccess, EntityCustomer, customerID, nil, nil); err != nil {
+		return nil, err
+	}
+
+	// the customer's lists are returned beside it, not inside it
+	data.Customer.Feedbacks, data.Customer.Interactions = nil, nil
+	return &PersonalData{
+		ExportedAt:   time.Now().UTC(),
+		Customer:     data.Customer,
+		Feedbacks:    data.Feedbacks,
+		Interactions: data.Interactions,
+	}, nil
+}
+
+// Erase implements PrivacyService. Ratings, products, channels and dates
+// survive so aggregate reports stay correct; names, contact details and
+// free text are overwritten, in the audit log too, and customer.erased
+// tells subscribers to do the same.
+func (s *privacyService) Erase(ctx context.Context, customerID uuid.UUID) (*ErasureResult, error) {
+	result := &ErasureResult{CustomerID: customerID}
+	err := s.tx.Transaction(ctx, func(tx *gorm.DB) error {
+		repo := s.repo.WithTx(tx)
+		c, err := repo.LockCustomer(ctx, customerID)
+		if err != nil {
+			return orNotFound(err, ErrCustomerNotFound)
+		}
+		if c.ErasedAt != nil {
+			result.ErasedAt = *c.ErasedAt
+			return nil
+		}
+
+		result.ErasedAt = time.Now().UTC()
+		if err := repo.Erase(ctx, customerID, result.ErasedAt); err != nil {
+			return err
+		}
+		if err := recordAudit(ctx, s.audit.WithTx(tx), AuditErase, EntityCustomer, customerID, nil, nil); err != nil {
+			return err
+		}
+		return recordEvent(ctx, s.outbox.WithTx(tx), event.CustomerErased, customerID, event.DeletedData{ID: customerID})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// checkNotErased rejects changes to a record of an erased customer, which
+// could write back the personal data erasure removed.
+func checkNotErased(ctx context.Context, customers repository.CustomerRepository, customerID uuid.UUID) error {
+	erased, err := customers.IsErased(ctx, customerID)
+	if err != nil {
+		return err
+	}
+	if erased {
+		return ErrCustomerErased
+	}
+	return nil
+}
+
+func NewPrivacyService(r repository.PrivacyRepository, outbox repository.OutboxRepository, audit repository.AuditRepository, tx repository.Transactor) PrivacyService {
+	return &privacyService{repo: r, outbox: outbox, audit: audit, tx: tx}
+}
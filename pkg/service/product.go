@@ -0,0 +1,121 @@
+package service
+
+import (
+	"context"
+	"customer-api/pkg/model"
+	"customer-api/pkg/pagination"
+	"customer-api/pkg/repository"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type ProductService interface {
+	Create(ctx context.Context, req *CreateProductRequest) (*model.Product, error)
+	Get(ctx context.Context, id uuid.UUID) (*model.Product, error)
+	Update(ctx context.Context, id uuid.UUID, req *UpdateProductRequest) (*model.Product, error)
+	Delete(ctx context.Context, id uuid.UUID) error
+	List(ctx context.Context, query, category string, p pagination.Params) (pagination.Page[model.Product], error)
+}
+
+type productService struct {
+	repo  repository.ProductRepository
+	audit repository.AuditRepository
+	tx    repository.Transactor
+}
+
+type CreateProductRequest struct {
+	Name     string `json:"name" validate:"required,max=100"`
+	Category string `json:"category" validate:"max=50"`
+}
+
+type UpdateProductRequest struct {
+	Name     *string `json:"name" validate:"omitempty,min=1,max=100"`
+	Category *string `json:"category" validate:"omitempty,max=50"`
+}
+
+// Create implements ProductService.
+func (s *productService) Create(ctx context.Context, req *CreateProductRequest) (*model.Product, error) {
+	p := &model.Product{
+		Name:     req.Name,
+		Category: req.Category,
+	}
+
+	err := s.tx.Transaction(ctx, func(tx *gorm.DB) error {
+		if err := s.repo.WithTx(tx).Create(ctx, p); err != nil {
+			return err
+		}
+		return recordAudit(ctx, s.audit.WithTx(tx), AuditCreate, EntityProduct, p.ID, nil, productAudit(p))
+	})
+	if err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// Delete implements ProductService.
+func (s *productService) Delete(ctx context.Context, id uuid.UUID) error {
+	return s.tx.Transaction(ctx, func(tx *gorm.DB) error {
+		repo := s.repo.WithTx(tx)
+		p, err := repo.GetForUpdate(ctx, id)
+		if err != nil {
+			return orNotFound(err, ErrProductNotFound)
+		}
+		if err := repo.Delete(ctx, id); err != nil {
+			return err
+		}
+		return recordAudit(ctx, s.audit.WithTx(tx), AuditDelete, EntityProduct, id, productAudit(p), nil)
+	})
+}
+
+// Get implements ProductService.
+func (s *productService) Get(ctx context.Context, id uuid.UUID) (*model.Product, error) {
+	p, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, orNotFound(err, ErrProductNotFound)
+	}
+	return p, nil
+}
+
+// List implements ProductService.
+func (s *productService) List(ctx context.Context, query, category string, p pagination.Params) (pagination.Page[model.Product], error) {
+	return s.repo.List(ctx, query, category, p.WithDefaults())
+}
+
+// Update implements ProductService. The product is read under a row lock,
+// so the audited before state is what this update overwrites.
+func (s *productService) Update(ctx context.Context, id uuid.UUID, req *UpdateProductRequest) (*model.Product, error) {
+	var p *model.Product
+	err := s.tx.Transaction(ctx, func(tx *gorm.DB) error {
+		repo := s.repo.WithTx(tx)
+		var err error
+		if p, err = repo.GetForUpdate(ctx, id); err != nil {
+			return orNotFound(err, ErrProductNotFound)
+		}
+		before := productAudit(p)
+
+		if req.Name != nil {
+			p.Name = *req.Name
+		}
+		if req.Category != nil {
+			p.Category = *req.Category
+		}
+		if err := repo.Update(ctx, p); err != nil {
+			return err
+		}
+		return recordAudit(ctx, s.audit.WithTx(tx), AuditUpdate, EntityProduct, p.ID, before, productAudit(p))
+	})
+	if err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// productAudit is the audited state of a product.
+func productAudit(p *model.Product) map[string]any {
+	return map[string]any{"name": p.Name, "category": p.Category}
+}
+
+func NewProductService(r repository.ProductRepository, audit repository.AuditRepository, tx repository.Transactor) ProductService {
+	return &productService{repo: r, audit: audit, tx: tx}
+}
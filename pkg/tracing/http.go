@@ -0,0 +1,21 @@
+package tracing
+
+import (
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
+)
+
+// Middleware starts a server span per request, named after the route
+// template, continuing any trace context sent by the caller. Prometheus
+// scrapes and health probes are not traced.
+func Middleware() gin.HandlerFunc {
+	return otelgin.Middleware(ServiceName, otelgin.WithGinFilter(func(c *gin.Context) bool {
+		return !untraced[c.FullPath()]
+	}))
+}
+
+var untraced = map[string]bool{
+	"/metrics": true,
+	"/livez":   true,
+	"/readyz":  true,
+}
@@ -0,0 +1,85 @@
+// Package validation checks request structs and reports failures per field.
+package validation
+
+import (
+	"errors"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// enums are the tags added by RegisterEnum.
+var enums = map[string][]string{}
+
+// RegisterEnum adds a tag accepting only the given strings, so a list kept
+// in one place backs both validation and the API document. It must be
+// called during package initialisation, before New.
+func RegisterEnum(tag string, values []string) {
+	enums[tag] = values
+}
+
+// Enum returns the values of a tag added by RegisterEnum.
+func Enum(tag string) ([]string, bool) {
+	values, ok := enums[tag]
+	return values, ok
+}
+
+// New returns a validator that reports fields by their JSON name.
+func New() *validator.Validate {
+	v := validator.New()
+	v.RegisterTagNameFunc(func(f reflect.StructField) string {
+		name := strings.SplitN(f.Tag.Get("json"), ",", 2)[0]
+		if name == "-" {
+			return ""
+		}
+		return name
+	})
+	for tag, values := range enums {
+		err := v.RegisterValidation(tag, func(fl validator.FieldLevel) bool {
+			return slices.Contains(values, fl.Field().String())
+		})
+		if err != nil {
+			panic("validation: " + err.Error())
+		}
+	}
+	return v
+}
+
+// Fields turns validator errors into a field -> message map, or nil when
+// err is not a validation failure.
+func Fields(err error) map[string]string {
+	var verrs validator.ValidationErrors
+	if !errors.As(err, &verrs) {
+		return nil
+	}
+
+	fields := make(map[string]string, len(verrs))
+	for _, fe := range verrs {
+		fields[fe.Field()] = message(fe)
+	}
+	return fields
+}
+
+func message(fe validator.FieldError) string {
+	switch fe.Tag() {
+	case "required":
+		return "is required"
+	case "uuid":
+		return "must be a valid UUID"
+	case "email":
+		return "must be a valid email address"
+	case "min":
+		return "must be at least " + fe.Param()
+	case "max":
+		return "must be at most " + fe.Param()
+	case "oneof":
+		return "must be one of: " + fe.Param()
+	default:
+		if values, ok := enums[fe.Tag()]; ok {
+			return "must be one of: " + strings.Join(values, ", ")
+		}
+		return "failed " + fe.Tag() + " validation"
+	}
+}
@@ -0,0 +1,51 @@
+package validation
+
+import "testing"
+
+func init() {
+	RegisterEnum("colour", []string{"red", "green"})
+}
+
+func TestEnumTag(t *testing.T) {
+	type request struct {
+		Colour string  `json:"colour" validate:"required,colour"`
+		Accent *string `json:"accent" validate:"omitempty,colour"`
+	}
+	blue := "blue"
+	green := "green"
+
+	tests := []struct {
+		name   string
+		req    request
+		fields map[string]string
+	}{
+		{"known value", request{Colour: "red"}, nil},
+		{"known optional value", request{Colour: "red", Accent: &green}, nil},
+		{"unknown value", request{Colour: "blue"}, map[string]string{"colour": "must be one of: red, green"}},
+		{"unknown optional value", request{Colour: "red", Accent: &blue}, map[string]string{"accent": "must be one of: red, green"}},
+		{"missing value", request{}, map[string]string{"colour": "is required"}},
+	}
+	v := New()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fields := Fields(v.Struct(tt.req))
+			if len(fields) != len(tt.fields) {
+				t.Fatalf("fields = %v, want %v", fields, tt.fields)
+			}
+			for k, want := range tt.fields {
+				if fields[k] != want {
+					t.Errorf("fields[%q] = %q, want %q", k, fields[k], want)
+				}
+			}
+		})
+	}
+}
+
+func TestEnum(t *testing.T) {
+	if values, ok := Enum("colour"); !ok || len(values) != 2 {
+		t.Errorf("Enum(colour) = %v, %v", values, ok)
+	}
+	if _, ok := Enum("oneof"); ok {
+		t.Error("Enum(oneof) reported a built-in tag")
+	}
+}
@@ -0,0 +1,48 @@
+package main
+
+import "customer-api/pkg/auth"
+
+// routePolicy is the permission each route requires when auth is enabled.
+// Startup fails if a registered route is missing from this table.
+var routePolicy = auth.Policy{
+	"GET /customers":                   auth.CustomersRead,
+	"POST /customers":                  auth.CustomersWrite,
+	"POST /customers/import":           auth.CustomersWrite,
+	"GET /customers/:id":               auth.CustomersRead,
+	"PUT /customers/:id":               auth.CustomersWrite,
+	"DELETE /customers/:id":            auth.CustomersDelete,
+	"GET /customers/:id/interactions":  auth.InteractionsRead,
+	"POST /customers/:id/interactions": auth.InteractionsWrite,
+	"GET /customers/:id/personal-data": auth.PersonalDataExport,
+	"POST /customers/:id/erase":        auth.PersonalDataErase,
+
+	"GET /products":        auth.ProductsRead,
+	"POST /products":       auth.ProductsWrite,
+	"GET /products/:id":    auth.ProductsRead,
+	"PUT /products/:id":    auth.ProductsWrite,
+	"DELETE /products/:id": auth.ProductsDelete,
+
+	"GET /feedbacks":        auth.FeedbackRead,
+	"POST /feedbacks":       auth.FeedbackWrite,
+	"GET /feedbacks/:id":    auth.FeedbackRead,
+	"PUT /feedbacks/:id":    auth.FeedbackWrite,
+	"DELETE /feedbacks/:id": auth.FeedbackDelete,
+
+	"GET /interactions":        auth.InteractionsRead,
+	"GET /interactions/:id":    auth.InteractionsRead,
+	"PUT /interactions/:id":    auth.InteractionsWrite,
+	"DELETE /interactions/:id": auth.InteractionsDelete,
+
+	"GET /exports/customers":    auth.ExportsRead,
+	"GET /exports/feedbacks":    auth.ExportsRead,
+	"GET /exports/interactions": auth.ExportsRead,
+
+	"POST /publish": auth.Publish,
+
+	"GET /api-keys":        auth.ManageAPIKeys,
+	"POST /api-keys":       auth.ManageAPIKeys,
+	"GET /api-keys/:id":    auth.ManageAPIKeys,
+	"DELETE /api-keys/:id": auth.ManageAPIKeys,
+
+	"GET /audit-logs": auth.AuditRead,
+}
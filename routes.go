@@ -0,0 +1,99 @@
+package main
+
+import (
+	"customer-api/pkg/handler"
+	"customer-api/pkg/health"
+	"customer-api/pkg/metrics"
+	"customer-api/pkg/openapi"
+
+	"github.com/gin-gonic/gin"
+)
+
+// handlers serve the API routes. A nil kafka handler leaves out
+// POST /publish.
+type handlers struct {
+	customers    *handler.CustomerHandler
+	products     *handler.ProductHandler
+	interactions *handler.InteractionHandler
+	feedbacks    *handler.FeedbackHandler
+	apiKeys      *handler.APIKeyHandler
+	audit        *handler.AuditHandler
+	exports      *handler.ExportHandler
+	privacy      *handler.PrivacyHandler
+	kafka        *handler.KafkaHandler
+}
+
+// registerPublicRoutes registers the routes served without credentials,
+// so it must run before the auth middleware is added.
+func registerPublicRoutes(r *gin.Engine, checker *health.Checker, doc *openapi.Document, withMetrics bool) {
+	r.GET("/livez", checker.Live)
+	r.GET("/readyz", checker.Ready)
+	if withMetrics {
+		r.GET("/metrics", gin.WrapH(metrics.Handler()))
+	}
+	r.GET("/openapi.json", doc.Handler())
+	r.GET("/docs/*filepath", openapi.UI("/openapi.json"))
+}
+
+// registerRoutes registers the API routes. Each needs an entry in
+// routePolicy and routeDocs, and may have one in routeLimits.
+func registerRoutes(r *gin.Engine, h handlers) {
+	customer := r.Group("customers")
+	customer.GET("", h.customers.Get)
+	customer.POST("", h.customers.CreateCustomer)
+	customer.POST("/import", h.customers.ImportCustomers)
+	customer.DELETE("/:id", h.customers.DeleteByID)
+	customer.PUT("/:id", h.customers.UpdateByID)
+	customer.GET("/:id", h.customers.GetByID)
+	customer.POST("/:id/interactions", h.interactions.CreateInteraction)
+	customer.GET("/:id/interactions", h.interactions.ListCustomerInteractions)
+	customer.GET("/:id/personal-data", h.privacy.ExportPersonalData)
+	customer.POST("/:id/erase", h.privacy.ErasePersonalData)
+
+	productGroup := r.Group("/products")
+	{
+		productGroup.POST("", h.products.CreateProduct)
+		productGroup.GET("", h.products.ListProducts)
+		productGroup.GET("/:id", h.products.GetProduct)
+		productGroup.PUT("/:id", h.products.UpdateProduct)
+		productGroup.DELETE("/:id", h.products.DeleteProduct)
+	}
+
+	feedbackGroup := r.Group("/feedbacks")
+	{
+		feedbackGroup.POST("", h.feedbacks.CreateFeedback)
+		feedbackGroup.GET("", h.feedbacks.ListFeedbacks)
+		feedbackGroup.GET("/:id", h.feedbacks.GetFeedback)
+		feedbackGroup.PUT("/:id", h.feedbacks.UpdateFeedback)
+		feedbackGroup.DELETE("/:id", h.feedbacks.DeleteFeedback)
+	}
+
+	interactionGroup := r.Group("/interactions")
+	{
+		interactionGroup.GET("", h.interactions.ListInteractions)
+		interactionGroup.GET("/:id", h.interactions.GetInteraction)
+		interactionGroup.PUT("/:id", h.interactions.UpdateInteraction)
+		interactionGroup.DELETE("/:id", h.interactions.DeleteInteraction)
+	}
+
+	apiKeyGroup := r.Group("/api-keys")
+	{
+		apiKeyGroup.POST("", h.apiKeys.CreateAPIKey)
+		apiKeyGroup.GET("", h.apiKeys.ListAPIKeys)
+		apiKeyGroup.GET("/:id", h.apiKeys.GetAPIKey)
+		apiKeyGroup.DELETE("/:id", h.apiKeys.RevokeAPIKey)
+	}
+
+	r.GET("/audit-logs", h.audit.ListAuditLogs)
+
+	exportGroup := r.Group("/exports")
+	{
+		exportGroup.GET("/customers", h.exports.ExportCustomers)
+		exportGroup.GET("/feedbacks", h.exports.ExportFeedbacks)
+		exportGroup.GET("/interactions", h.exports.ExportInteractions)
+	}
+
+	if h.kafka != nil {
+		r.POST("/publish", h.kafka.Publish)
+	}
+}